@@ -5,11 +5,12 @@ package main
 import (
 	"github.com/charmbracelet/log"
 	"github.com/corani/chip-8/internal/chip8"
+	"github.com/corani/chip-8/internal/config"
 	"github.com/corani/chip-8/internal/gui"
 )
 
 func init() {
-	availableUIs.Register("gui", func(log *log.Logger, chip8 *chip8.Chip8) App {
-		return gui.New(log, chip8)
+	availableUIs.Register("gui", func(log *log.Logger, chip8 *chip8.Chip8, cfg *config.Config) App {
+		return gui.New(log, chip8, cfg)
 	})
 }
@@ -1,22 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"runtime/pprof"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/corani/chip-8/internal/chip8"
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/display"
+	"github.com/corani/chip-8/internal/keymap"
+	"github.com/corani/chip-8/internal/palette"
+	"github.com/corani/chip-8/internal/romdb"
+	"github.com/corani/chip-8/internal/watch"
 )
 
 type App interface {
 	Run() error
 }
 
-type AppBuilder func(*log.Logger, *chip8.Chip8) App
+type AppBuilder func(*log.Logger, *chip8.Chip8, *config.Config) App
 
 type UIs map[string]AppBuilder
 
@@ -38,10 +50,44 @@ var availableUIs = UIs{}
 
 func main() {
 	cpuprofile := flag.String("cpuprofile", "", "write cpu profile to file")
-	romfile := flag.String("rom", "", "path to the rom file")
+	romfile := flag.String("rom", "", "path to the rom file (if omitted, a rom browser is shown)")
+	romdir := flag.String("roms", ".", "directory to browse for roms if -rom is omitted")
 	logfile := flag.String("log", "", "path to the log file")
+	turbo := flag.Float64("turbo", 4, "speed multiplier while fast-forwarding")
+	slow := flag.Float64("slow", 0.25, "speed multiplier in slow motion")
+	renderer := flag.String("tui-renderer", "block", "how the tui draws pixels (block, half, braille, graphics, kitty, sixel)")
+	keyTap := flag.Duration("key-tap", 30*time.Millisecond, "how long the tui holds a key that was tapped")
+	keyDelay := flag.Duration("key-delay", 500*time.Millisecond, "how long after a press the terminal starts repeating a key")
+	keyRepeat := flag.Duration("key-repeat", 100*time.Millisecond, "how long the tui holds a key between repeats of the terminal")
+	bell := flag.Bool("bell", false, "ring the terminal bell when the tui beeps")
+	keypad := flag.Bool("keypad", false, "show a keypad that can be clicked with the mouse")
+	flag.String("keymap", "", fmt.Sprintf("keyboard layout (%s) and/or keys, e.g. `azerty,space=5`",
+		strings.Join(keymap.Names(), ", ")))
+	pad1 := flag.String("pad1", "", "gamepad buttons of player 1 to keys, e.g. `a=6,up=5`")
+	pad2 := flag.String("pad2", "", "gamepad buttons of player 2 to keys, e.g. `a=6,up=5`")
+	castfile := flag.String("cast", "", "record the tui session as an asciinema `.cast` file")
+	scanlines := flag.Bool("scanlines", false, "darken the lower half of every row of pixels in the gui")
+	grid := flag.Bool("grid", false, "leave gaps between the pixels in the gui")
+	bloom := flag.Bool("bloom", false, "let lit pixels glow in the gui")
+	scaling := flag.String("scaling", "fit", "how the gui scales the screen (fit, integer)")
+	captureScale := flag.Int("capture-scale", 8, "scale of screenshots and gifs taken in the gui")
+	captureColors := flag.String("capture-colors", "", "theme or colors of screenshots and gifs (default: -theme)")
+	vblank := flag.Bool("vblank", false, "only show complete frames, once per 60Hz frame")
+	blend := flag.Bool("blend", false, "show pixels set in either of the last two frames")
+	decay := flag.Int("decay", 0, "fade out pixels over this many frames")
+	flag.String("theme", "", fmt.Sprintf("color theme (%s) or colors, e.g. `#000000,#ffffff`",
+		strings.Join(palette.Names(), ", ")))
+	watchRom := flag.Bool("watch", false, "reload and reset when the rom file changes")
+	dbfile := flag.String("romdb", "", "path to the chip-8-database `programs.json` (or its directory)")
 	ui := flag.String("ui", "tui", fmt.Sprintf("user interface to use (%s)",
 		strings.Join(availableUIs.Available(), ", ")))
+	flag.String("platform", "", fmt.Sprintf("quirks of a platform (%s)", strings.Join(platformIDs(), ", ")))
+	flag.String("quirks", "", "quirks to enable or disable, e.g. `shift,wrap=false`")
+	flag.Uint("speed", 0, "instructions per second (default: from -romdb, or 500)")
+	scale := flag.Int("scale", 0, "initial scale of the gui window (default: 640x480)")
+	flag.Int("sound", 440, "pitch of the beep of the gui in Hz")
+	configFile := flag.String("config", "", "path to the config file (default: chip-8/chip8.conf in the user config directory)")
+	saveConfig := flag.Bool("save-config", false, "save the flags given on the command line to the config file, for the rom if given")
 	help := flag.Bool("help", false, "show this help message")
 	flag.Parse()
 
@@ -54,11 +100,13 @@ func main() {
 	var out io.Writer
 
 	if *logfile != "" {
-		out, err := os.Create(*logfile)
+		f, err := os.Create(*logfile)
 		if err != nil {
 			panic(err)
 		}
-		defer out.Close()
+		defer f.Close()
+
+		out = f
 	} else {
 		out = io.Discard
 	}
@@ -66,11 +114,6 @@ func main() {
 	logger := log.New(io.MultiWriter(out, os.Stderr))
 	logger.SetReportTimestamp(true)
 
-	if romfile == nil {
-		logger.Errorf("Usage: %s -rom <rom-file>", os.Args[0])
-		os.Exit(1)
-	}
-
 	var rom []byte
 
 	if *romfile != "" {
@@ -83,6 +126,13 @@ func main() {
 		rom = bs
 	}
 
+	// remember the flags given on the command line, before the config file sets
+	// the others.
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	configPath, file := loadConfig(logger, *configFile, *romfile, rom, given)
+
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -97,14 +147,109 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	chip8 := chip8.New(logger, os.Args[1], rom)
+	chip8, err := chip8.New(logger, *romfile, rom)
+	if err != nil {
+		logger.Errorf("failed to load rom: %v", err)
+		os.Exit(1)
+	}
+	chip8.SetTurbo(*turbo, *slow)
+	chip8.SetFilter(display.Filter{VBlank: *vblank, Blend: *blend, Decay: *decay})
+
+	if *watchRom {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go watch.Poll(ctx, chip8.ROMFile, 250*time.Millisecond, chip8.QueueReload)
+	}
+
+	cfg := config.New()
+	cfg.ROMDir = *romdir
+	cfg.Renderer = *renderer
+	cfg.KeyTap = *keyTap
+	cfg.KeyDelay = *keyDelay
+	cfg.KeyRepeat = *keyRepeat
+	cfg.Bell = *bell
+	cfg.Keypad = *keypad
+	cfg.Cast = *castfile
+	cfg.CaptureScale = *captureScale
+
+	for i, pad := range []string{*pad1, *pad2} {
+		mapping, err := keymap.ParseKeys(pad)
+		if err != nil {
+			logger.Errorf("invalid mapping of gamepad %d: %v", i+1, err)
+			os.Exit(1)
+		}
+
+		cfg.Pads[i] = mapping
+	}
+
+	if *scaling != "fit" && *scaling != "integer" {
+		logger.Errorf("unknown scaling: %s (supported: fit, integer)", *scaling)
+		os.Exit(1)
+	}
+
+	cfg.CRT = config.CRT{
+		Scanlines: *scanlines,
+		Grid:      *grid,
+		Bloom:     *bloom,
+		Integer:   *scaling == "integer",
+	}
+
+	if *captureColors != "" {
+		p, err := palette.Parse(*captureColors)
+		if err != nil {
+			logger.Errorf("invalid capture colors: %v", err)
+			os.Exit(1)
+		}
+
+		cfg.CapturePalette = &p
+	}
+
+	if *scale < 0 {
+		logger.Errorf("invalid scale: %d", *scale)
+		os.Exit(1)
+	}
+
+	cfg.Scale = *scale
+
+	var db *romdb.Database
+
+	if *dbfile != "" {
+		if db, err = romdb.Load(*dbfile); err != nil {
+			logger.Errorf("failed to load rom database: %v", err)
+		}
+	}
+
+	settings := newROMSettings(logger, chip8, file, db, given)
+
+	// ROMs that are picked or dropped later get their settings the same way
+	// as the one given with -rom.
+	cfg.ForROM = func(name string) *config.Config {
+		c, err := settings.apply(cfg, name)
+		if err != nil {
+			logger.Errorf("invalid settings for %s: %v", name, err)
+		}
+
+		return c
+	}
+
+	var name string
+	if *romfile != "" {
+		name = filepath.Base(*romfile)
+	}
+
+	romCfg, err := settings.apply(cfg, name)
+	if err != nil {
+		logger.Errorf("invalid settings: %v", err)
+		os.Exit(1)
+	}
 
 	var app App
 
 	if builder, ok := availableUIs[*ui]; ok {
 		logger.Infof("using user interface: %s", *ui)
 
-		app = builder(logger, chip8)
+		app = builder(logger, chip8, romCfg)
 	} else {
 		logger.Errorf("unknown user interface: %s (supported: %s)",
 			*ui, strings.Join(availableUIs.Available(), ", "))
@@ -112,6 +257,10 @@ func main() {
 
 	}
 
+	if *saveConfig {
+		saveSettings(logger, configPath, file, *romfile, given)
+	}
+
 	if *ui == "tui" {
 		// NOTE(daniel): from this point on, don't log to stderr anymore,
 		// as this messes up the TUI interface.
@@ -123,3 +272,82 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// unsaved are the flags that only apply to a single run, which can't be set
+// in the config file.
+var unsaved = []string{"rom", "log", "cpuprofile", "cast", "config", "save-config", "help"}
+
+// loadConfig reads the config file, and sets the flags that weren't `given` on
+// the command line to the settings for the ROM. It returns the path of the
+// config file and its contents, which are empty if it doesn't exist.
+func loadConfig(logger *log.Logger, path, romfile string, rom []byte, given map[string]bool) (string, *config.File) {
+	if path == "" {
+		p, err := config.DefaultPath()
+		if err != nil {
+			logger.Warnf("no config directory: %v", err)
+
+			return "", config.NewFile()
+		}
+
+		path = p
+	}
+
+	file, err := config.LoadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return path, config.NewFile()
+	} else if err != nil {
+		logger.Errorf("failed to load config: %v", err)
+		os.Exit(1)
+	}
+
+	logger.Infof("using config: %s", path)
+
+	for name, value := range file.Settings(romfile, rom) {
+		if flag.Lookup(name) == nil || slices.Contains(unsaved, name) {
+			logger.Warnf("ignoring unknown setting in config: %s", name)
+
+			continue
+		}
+
+		// flags given on the command line take precedence.
+		if given[name] {
+			continue
+		}
+
+		if err := flag.Set(name, value); err != nil {
+			logger.Errorf("invalid setting in config: %s = %s: %v", name, value, err)
+			os.Exit(1)
+		}
+	}
+
+	return path, file
+}
+
+// saveSettings saves the flags `given` on the command line to the config
+// file, in the section of the ROM if one was given, or else as global settings.
+func saveSettings(logger *log.Logger, path string, file *config.File, romfile string, given map[string]bool) {
+	if path == "" {
+		logger.Errorf("failed to save config: no config file")
+
+		return
+	}
+
+	settings := file.Global
+	if romfile != "" {
+		settings = file.Section(config.SectionName(romfile))
+	}
+
+	for name := range given {
+		if !slices.Contains(unsaved, name) {
+			settings[name] = flag.Lookup(name).Value.String()
+		}
+	}
+
+	if err := file.Save(path); err != nil {
+		logger.Errorf("failed to save config: %v", err)
+
+		return
+	}
+
+	logger.Infof("saved config: %s", path)
+}
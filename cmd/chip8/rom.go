@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/corani/chip-8/internal/chip8"
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/keymap"
+	"github.com/corani/chip-8/internal/palette"
+	"github.com/corani/chip-8/internal/quirks"
+	"github.com/corani/chip-8/internal/romdb"
+)
+
+// romSettings applies the settings that depend on the ROM: the ones from the
+// database, overridden by the ones in the config file, overridden by the ones
+// given on the command line. They're applied to the ROM given with `-rom`, and
+// again to every ROM that is picked or dropped later.
+type romSettings struct {
+	logger *log.Logger
+	chip8  *chip8.Chip8
+	file   *config.File
+	db     *romdb.Database // nil without `-romdb`
+	given  map[string]bool
+	quirks quirks.Quirks // of the machine, before any ROM was loaded
+	speed  uint
+}
+
+func newROMSettings(logger *log.Logger, chip8 *chip8.Chip8, file *config.File, db *romdb.Database, given map[string]bool) *romSettings {
+	return &romSettings{
+		logger: logger,
+		chip8:  chip8,
+		file:   file,
+		db:     db,
+		given:  given,
+		quirks: chip8.Quirks(),
+		speed:  chip8.Speed(),
+	}
+}
+
+// apply applies the settings of the ROM `name` that is loaded in the machine,
+// and returns a copy of `base` with its title, keys and colors. Invalid
+// settings are skipped, and returned as error.
+func (s *romSettings) apply(base *config.Config, name string) (*config.Config, error) {
+	romfile, rom := s.chip8.ROMFile(), s.chip8.ROM()
+	settings := s.file.Settings(romfile, rom)
+
+	cfg := *base
+	s.chip8.SetQuirks(s.quirks)
+	s.chip8.SetSpeed(s.speed)
+
+	if s.db != nil && len(rom) > 0 {
+		if title := s.applyDatabase(&cfg, rom); title != "" {
+			name = title
+		}
+	}
+
+	if name != "" {
+		cfg.Title = fmt.Sprintf("%s - %s", name, base.Title)
+	}
+
+	var errs []error
+
+	if err := s.applyQuirks(s.setting(settings, "platform"), s.setting(settings, "quirks")); err != nil {
+		errs = append(errs, err)
+	}
+
+	if speed := s.setting(settings, "speed"); speed != "" {
+		ips, err := strconv.ParseUint(speed, 0, strconv.IntSize)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid speed: %s", speed))
+		} else if ips > 0 {
+			s.chip8.SetSpeed(uint(ips))
+		}
+	}
+
+	if sound := s.setting(settings, "sound"); sound != "" {
+		hz, err := strconv.Atoi(sound)
+		if err != nil || hz <= 0 {
+			errs = append(errs, fmt.Errorf("invalid sound frequency: %s", sound))
+		} else {
+			cfg.Sound = hz
+		}
+	}
+
+	keymaps := s.file.Values("keymap", romfile, rom)
+	if s.given["keymap"] {
+		keymaps = append(keymaps, flag.Lookup("keymap").Value.String())
+	}
+
+	if k, err := loadKeymap(keymaps, cfg.Keys); err != nil {
+		errs = append(errs, err)
+	} else {
+		cfg.Keymap = k
+	}
+
+	if theme := s.setting(settings, "theme"); theme != "" {
+		p, err := palette.Parse(theme)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid theme: %w", err))
+		} else {
+			cfg.Palette = &p
+		}
+	}
+
+	return &cfg, errors.Join(errs...)
+}
+
+// setting returns the value of the flag `name` for the ROM: the one given on
+// the command line, or else the one in its `settings`, or else the default.
+func (s *romSettings) setting(settings config.Settings, name string) string {
+	f := flag.Lookup(name)
+
+	if s.given[name] {
+		return f.Value.String()
+	}
+
+	if value, ok := settings[name]; ok {
+		return value
+	}
+
+	return f.DefValue
+}
+
+// applyDatabase looks up the ROM in the database and applies its platform,
+// quirks, tick rate, keys and colors. It returns the title of the ROM, if it
+// was found.
+func (s *romSettings) applyDatabase(cfg *config.Config, rom []byte) string {
+	entry, ok := s.db.Lookup(rom)
+	if !ok {
+		s.logger.Infof("rom not found in database: %s", romdb.Hash(rom))
+
+		return ""
+	}
+
+	s.logger.Infof("identified rom: %s", entry.Name())
+
+	cfg.Keys = entry.Keys
+
+	if entry.Colors != nil {
+		p, err := palette.FromHex(entry.Colors.Pixels...)
+		if err != nil {
+			s.logger.Errorf("failed to apply colors: %v", err)
+		} else {
+			cfg.Palette = &p
+		}
+	}
+
+	platform, err := entry.Platform()
+	if err != nil {
+		s.logger.Errorf("failed to apply platform: %v", err)
+	} else {
+		s.logger.Infof("using platform: %s (%s)", platform.Name, platform.Quirks)
+
+		s.chip8.SetQuirks(platform.Quirks)
+	}
+
+	if entry.Tickrate > 0 {
+		// the tick rate is the number of instructions per 60Hz frame.
+		s.chip8.SetSpeed(entry.Tickrate * 60)
+	}
+
+	return entry.Name()
+}
+
+// applyQuirks applies the quirks of a platform, then the individual quirks,
+// given as `name` or `name=bool`.
+func (s *romSettings) applyQuirks(platform, list string) error {
+	if platform != "" {
+		p, ok := quirks.Lookup(platform)
+		if !ok {
+			return fmt.Errorf("unknown platform: %s (supported: %s)", platform, strings.Join(platformIDs(), ", "))
+		}
+
+		s.chip8.SetQuirks(p.Quirks)
+	}
+
+	if list == "" {
+		return nil
+	}
+
+	q := s.chip8.Quirks()
+
+	for _, item := range strings.Split(list, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+
+		enabled := true
+		if ok {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid quirk: %s", item)
+			}
+
+			enabled = b
+		}
+
+		if err := q.Set(name, enabled); err != nil {
+			return fmt.Errorf("invalid quirk: %w", err)
+		}
+	}
+
+	s.logger.Infof("using quirks: %s", q)
+
+	s.chip8.SetQuirks(q)
+
+	return nil
+}
+
+// loadKeymap builds the keymap from the semantic keys of the ROM and the
+// `keymaps`, in increasing order of precedence.
+func loadKeymap(keymaps []string, semantic map[string]uint8) (keymap.Keymap, error) {
+	mappings := make([]keymap.Mapping, 0, len(keymaps))
+
+	for _, keys := range keymaps {
+		m, err := keymap.Parse(keys)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keymap: %w", err)
+		}
+
+		mappings = append(mappings, m)
+	}
+
+	return keymap.Resolve(semantic, mappings...), nil
+}
+
+// platformIDs returns the IDs of the platforms.
+func platformIDs() []string {
+	ids := make([]string, 0, len(quirks.Platforms))
+	for _, p := range quirks.Platforms {
+		ids = append(ids, p.ID)
+	}
+
+	return ids
+}
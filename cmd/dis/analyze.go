@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/corani/chip-8/internal/quirks"
+)
+
+// evidence is an instruction whose behavior depends on a quirk.
+type evidence struct {
+	quirk string
+	addr  uint16
+	note  string
+}
+
+type analysis struct {
+	evidence []evidence
+	quirks   quirks.Quirks
+	platform quirks.Platform
+}
+
+// analyze scans the reachable code for instructions whose behavior depends on
+// ambiguous quirks, and recommends a platform and set of quirks.
+func analyze(rom ROM, graph *cfg) analysis {
+	var res analysis
+
+	add := func(quirk string, addr uint16, format string, args ...any) {
+		res.evidence = append(res.evidence, evidence{quirk, addr, fmt.Sprintf(format, args...)})
+	}
+
+	var (
+		shifts       int // shifts with x != y
+		shiftsFromV0 int // shifts with x != y and y == 0
+		jumps        int // jumps with x != 0
+		edgeDraws    int // sprites drawn across the edge of the screen
+	)
+
+	// registers with a known constant value at the end of each block.
+	exits := map[uint16]map[uint16]uint16{}
+
+	for _, b := range graph.sortedBlocks() {
+		consts := graph.entryConsts(rom, b.start, exits)
+		exits[b.start] = consts
+
+		for pc := b.start; pc <= b.end; pc += 2 {
+			instr := rom.word(pc)
+			m := instr >> 12
+			x := (instr & 0x0F00) >> 8
+			y := (instr & 0x00F0) >> 4
+			n := instr & 0x000F
+			kk := instr & 0x00FF
+
+			switch m {
+			case 0x6:
+				// 6xkk: LD Vx, byte
+				consts[x] = kk
+
+				continue
+			case 0x7:
+				// 7xkk: ADD Vx, byte
+				if v, ok := consts[x]; ok {
+					consts[x] = (v + kk) & 0xFF
+				}
+			case 0x8:
+				if x == 0xF || (y == 0xF && n != 0x0) {
+					add("vf", pc, "VF is used as an operand, the result depends on when the flag is written")
+				}
+
+				switch n {
+				case 0x6, 0xE:
+					// 8xy6: SHR Vx {, Vy}
+					// 8xyE: SHL Vx {, Vy}
+					if x != y {
+						shifts++
+
+						if y == 0 {
+							shiftsFromV0++
+						}
+
+						add("shift", pc, "shifts V%x with x != y, the result depends on whether V%x is used", x, y)
+					}
+				}
+
+				// every 8xy_ instruction writes Vx, and most of them VF.
+				delete(consts, x)
+				delete(consts, 0xF)
+			case 0xB:
+				// Bnnn: JP V0, addr
+				if x != 0 {
+					jumps++
+
+					add("jump", pc, "jumps to %04x+V0, or %04x+V%x", instr&0x0FFF, instr&0x0FFF, x)
+				}
+			case 0xC:
+				// Cxkk: RND Vx, byte
+				delete(consts, x)
+			case 0xD:
+				// Dxyn: DRW Vx, Vy, nibble
+				vx, okx := consts[x]
+				vy, oky := consts[y]
+
+				if (okx && vx > 64-8) || (oky && vy+n > 32) {
+					edgeDraws++
+
+					add("wrap", pc, "draws a sprite across the edge of the screen, it is either wrapped or clipped")
+				}
+
+				// the collision flag.
+				delete(consts, 0xF)
+			case 0xF:
+				switch kk {
+				case 0x07, 0x0A:
+					// Fx07: LD Vx, DT
+					// Fx0A: LD Vx, K
+					delete(consts, x)
+				case 0x55, 0x65:
+					// Fx55: LD [I], Vx
+					// Fx65: LD Vx, [I]
+					if graph.inLoop(b.start) && !graph.setsI(rom, b.start) {
+						add("memory", pc, "reads or writes memory in a loop without setting I, it relies on I being incremented")
+					}
+
+					if kk == 0x65 {
+						// Fx65 writes V0 to Vx.
+						for r := range x + 1 {
+							delete(consts, r)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// start from the modern interpretation, and only deviate if the evidence
+	// is conclusive. Memory accesses that rely on I being incremented already
+	// match the modern interpretation, and the order in which VF is written
+	// isn't a quirk of any platform.
+	res.quirks = quirks.Quirks{}
+
+	if shifts > 0 && shifts == shiftsFromV0 {
+		// assemblers for SUPER-CHIP encode `SHR Vx` as 8x06, so if Vy is always
+		// V0, the ROM most likely expects in-place shifts.
+		res.quirks.Shift = true
+	}
+
+	// with x == 0 both interpretations jump to the same address, so Bxnn
+	// with x != 0 points to a ROM written for the SUPER-CHIP behavior.
+	res.quirks.Jump = jumps > 0
+
+	// a sprite drawn across the edge at a fixed position is only fully
+	// visible if it wraps.
+	res.quirks.Wrap = edgeDraws > 0
+
+	res.platform = quirks.Closest(res.quirks)
+
+	sort.SliceStable(res.evidence, func(i, j int) bool {
+		return res.evidence[i].quirk < res.evidence[j].quirk
+	})
+
+	return res
+}
+
+// entryConsts returns the registers with a known constant value when entering
+// the block at `addr`: the ones all blocks leading to it agree on. Blocks that
+// haven't been analyzed yet (loops), routines and the return from a call don't
+// carry over any values.
+func (g *cfg) entryConsts(rom ROM, addr uint16, exits map[uint16]map[uint16]uint16) map[uint16]uint16 {
+	var consts map[uint16]uint16
+
+	for _, b := range g.blocks {
+		for _, e := range b.edges {
+			if e.to != addr {
+				continue
+			}
+
+			exit, ok := exits[b.start]
+			if !ok || e.kind == edgeCall || rom.word(b.end)>>12 == 0x2 {
+				return map[uint16]uint16{}
+			}
+
+			if consts == nil {
+				consts = maps.Clone(exit)
+
+				continue
+			}
+
+			for r, v := range consts {
+				if w, ok := exit[r]; !ok || w != v {
+					delete(consts, r)
+				}
+			}
+		}
+	}
+
+	if consts == nil {
+		return map[uint16]uint16{}
+	}
+
+	return consts
+}
+
+// inLoop reports whether the block at `addr` is part of a loop, i.e. if it can
+// reach itself without following calls.
+func (g *cfg) inLoop(addr uint16) bool {
+	seen := map[uint16]bool{}
+	queue := []uint16{addr}
+
+	for len(queue) > 0 {
+		b, ok := g.blocks[queue[0]]
+		queue = queue[1:]
+
+		if !ok {
+			continue
+		}
+
+		for _, e := range b.edges {
+			if e.kind == edgeCall {
+				continue
+			}
+
+			if e.to == addr {
+				return true
+			}
+
+			if !seen[e.to] {
+				seen[e.to] = true
+				queue = append(queue, e.to)
+			}
+		}
+	}
+
+	return false
+}
+
+// setsI reports whether any block in the loop through `addr` sets I with
+// `LD I, addr`, `ADD I, Vx` or `LD F, Vx`.
+func (g *cfg) setsI(rom ROM, addr uint16) bool {
+	// blocks that can reach `addr`.
+	preds := map[uint16][]uint16{}
+
+	for _, b := range g.blocks {
+		for _, e := range b.edges {
+			if e.kind != edgeCall {
+				preds[e.to] = append(preds[e.to], b.start)
+			}
+		}
+	}
+
+	back := map[uint16]bool{addr: true}
+	queue := []uint16{addr}
+
+	for len(queue) > 0 {
+		for _, p := range preds[queue[0]] {
+			if !back[p] {
+				back[p] = true
+				queue = append(queue, p)
+			}
+		}
+
+		queue = queue[1:]
+	}
+
+	// blocks that can be reached from `addr` and can reach `addr` form the
+	// loop.
+	seen := map[uint16]bool{addr: true}
+	queue = []uint16{addr}
+
+	for len(queue) > 0 {
+		b, ok := g.blocks[queue[0]]
+		queue = queue[1:]
+
+		if !ok {
+			continue
+		}
+
+		for pc := b.start; pc <= b.end; pc += 2 {
+			instr := rom.word(pc)
+			kk := instr & 0x00FF
+
+			if instr>>12 == 0xA || (instr>>12 == 0xF && (kk == 0x1E || kk == 0x29)) {
+				return true
+			}
+		}
+
+		for _, e := range b.edges {
+			if e.kind != edgeCall && back[e.to] && !seen[e.to] {
+				seen[e.to] = true
+				queue = append(queue, e.to)
+			}
+		}
+	}
+
+	return false
+}
+
+func writeAnalysis(w io.Writer, rom ROM, res analysis) {
+	quirk := ""
+
+	for _, e := range res.evidence {
+		if e.quirk != quirk {
+			quirk = e.quirk
+			fmt.Fprintf(w, "%s:\n", quirk)
+		}
+
+		fmt.Fprintf(w, "\t%04x\t%s\t; %s\n", e.addr, decode(rom.word(e.addr)), e.note)
+	}
+
+	if len(res.evidence) == 0 {
+		fmt.Fprintln(w, "no quirk-dependent instructions found")
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "platform: %s (%s)\n", res.platform.ID, res.platform.Name)
+
+	if diff := res.quirks.Diff(res.platform.Quirks); len(diff) > 0 {
+		fmt.Fprintf(w, "differs in: %s\n", strings.Join(diff, " "))
+	}
+
+	fmt.Fprintf(w, "quirks: %s\n", res.quirks)
+}
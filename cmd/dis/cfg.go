@@ -0,0 +1,212 @@
+package main
+
+import "sort"
+
+type edgeKind string
+
+const (
+	edgeFallthrough edgeKind = "fallthrough"
+	edgeJump        edgeKind = "jump"
+	edgeCall        edgeKind = "call"
+	edgeSkip        edgeKind = "skip"
+)
+
+type edge struct {
+	to   uint16
+	kind edgeKind
+}
+
+// block is a basic block: a straight run of instructions that is only entered
+// at the top and only left at the bottom.
+type block struct {
+	start uint16
+	end   uint16 // address of the last instruction in the block
+	edges []edge
+}
+
+type cfg struct {
+	blocks map[uint16]*block
+	// routine entry for each block, if it belongs to a routine.
+	routine map[uint16]uint16
+}
+
+// successors returns the outgoing edges of the instruction at `pc`, and
+// whether the instruction ends a basic block.
+func successors(pc, instr uint16) ([]edge, bool) {
+	m := instr >> 12
+	kk := instr & 0x00FF
+	addr := instr & 0x0FFF
+
+	switch m {
+	case 0x0:
+		if addr == 0x0EE {
+			// 00EE: RET
+			return nil, true
+		}
+	case 0x1:
+		// 1nnn: JP addr
+		return []edge{{addr, edgeJump}}, true
+	case 0x2:
+		// 2nnn: CALL addr
+		return []edge{{addr, edgeCall}, {pc + 2, edgeFallthrough}}, true
+	case 0x3, 0x4, 0x5, 0x9:
+		// 3xkk: SE Vx, byte
+		// 4xkk: SNE Vx, byte
+		// 5xy0: SE Vx, Vy
+		// 9xy0: SNE Vx, Vy
+		return []edge{{pc + 2, edgeFallthrough}, {pc + 4, edgeSkip}}, true
+	case 0xB:
+		// Bnnn: JP V0, addr
+		// the actual target depends on V0, we can only follow the base address
+		// of the table.
+		return []edge{{addr, edgeJump}}, true
+	case 0xE:
+		switch kk {
+		case 0x9E, 0xA1:
+			// Ex9E: SKP Vx
+			// ExA1: SKNP Vx
+			return []edge{{pc + 2, edgeFallthrough}, {pc + 4, edgeSkip}}, true
+		}
+	}
+
+	return []edge{{pc + 2, edgeFallthrough}}, false
+}
+
+// buildCFG follows the control flow of the ROM, starting at the entry point,
+// and splits the reachable instructions into basic blocks. Addresses that are
+// part of a sprite are never treated as code.
+func buildCFG(rom ROM, labels map[uint16]string, data map[uint16]bool) *cfg {
+	isCode := func(pc uint16) bool {
+		return pc >= start && pc+1 < rom.end() && !data[pc] && !data[pc+1]
+	}
+
+	// find all reachable instructions, and the addresses that start a block.
+	reachable := map[uint16]bool{}
+	leaders := map[uint16]bool{start: true}
+	queue := []uint16{start}
+
+	for len(queue) > 0 {
+		pc := queue[0]
+		queue = queue[1:]
+
+		if reachable[pc] || !isCode(pc) {
+			continue
+		}
+
+		reachable[pc] = true
+
+		edges, ends := successors(pc, rom.word(pc))
+
+		for _, e := range edges {
+			if ends {
+				leaders[e.to] = true
+			}
+
+			queue = append(queue, e.to)
+		}
+	}
+
+	for addr := range labels {
+		leaders[addr] = true
+	}
+
+	addrs := make([]uint16, 0, len(reachable))
+	for pc := range reachable {
+		addrs = append(addrs, pc)
+	}
+
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	// split the reachable instructions into blocks.
+	graph := &cfg{
+		blocks:  map[uint16]*block{},
+		routine: map[uint16]uint16{},
+	}
+
+	var current *block
+
+	for _, pc := range addrs {
+		if current == nil || leaders[pc] || current.end+2 != pc {
+			current = &block{start: pc}
+			graph.blocks[pc] = current
+		}
+
+		current.end = pc
+
+		edges, ends := successors(pc, rom.word(pc))
+
+		next := pc + 2
+		if ends || leaders[next] || !reachable[next] {
+			for _, e := range edges {
+				if isCode(e.to) {
+					current.edges = append(current.edges, e)
+				}
+			}
+
+			current = nil
+		}
+	}
+
+	graph.assignRoutines()
+
+	return graph
+}
+
+// assignRoutines assigns each block to the routine it belongs to, by following
+// all edges except calls from the routine's entry point. Blocks that can be
+// reached from more than one routine belong to the one with the lowest address.
+func (g *cfg) assignRoutines() {
+	var entries []uint16
+
+	for _, b := range g.blocks {
+		for _, e := range b.edges {
+			if e.kind == edgeCall {
+				entries = append(entries, e.to)
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i] < entries[j] })
+
+	for _, entry := range entries {
+		if _, ok := g.routine[entry]; ok {
+			continue
+		}
+
+		queue := []uint16{entry}
+
+		for len(queue) > 0 {
+			addr := queue[0]
+			queue = queue[1:]
+
+			b, ok := g.blocks[addr]
+			if !ok {
+				continue
+			}
+
+			if _, ok := g.routine[addr]; ok {
+				continue
+			}
+
+			g.routine[addr] = entry
+
+			for _, e := range b.edges {
+				if e.kind != edgeCall {
+					queue = append(queue, e.to)
+				}
+			}
+		}
+	}
+}
+
+// sortedBlocks returns the blocks in address order.
+func (g *cfg) sortedBlocks() []*block {
+	blocks := make([]*block, 0, len(g.blocks))
+	for _, b := range g.blocks {
+		blocks = append(blocks, b)
+	}
+
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].start < blocks[j].start })
+
+	return blocks
+}
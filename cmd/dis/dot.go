@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var edgeStyles = map[edgeKind]string{
+	edgeFallthrough: "solid",
+	edgeJump:        "bold",
+	edgeCall:        "dashed",
+	edgeSkip:        "dotted",
+}
+
+// writeDot writes the control flow graph as a Graphviz DOT graph, with the
+// blocks of each routine grouped in a cluster.
+func writeDot(w io.Writer, rom ROM, labels map[uint16]string, graph *cfg) {
+	fmt.Fprintln(w, "digraph chip8 {")
+	fmt.Fprintln(w, "\tnode [shape=box, fontname=monospace];")
+	fmt.Fprintln(w, "\tedge [fontname=monospace, fontsize=10];")
+
+	clusters := map[uint16][]*block{}
+
+	for _, b := range graph.sortedBlocks() {
+		if entry, ok := graph.routine[b.start]; ok {
+			clusters[entry] = append(clusters[entry], b)
+		} else {
+			writeDotBlock(w, "\t", rom, labels, b)
+		}
+	}
+
+	for _, b := range graph.sortedBlocks() {
+		blocks, ok := clusters[b.start]
+		if !ok {
+			continue
+		}
+
+		name := labels[b.start]
+		if name == "" {
+			name = fmt.Sprintf("%04x", b.start)
+		}
+
+		fmt.Fprintf(w, "\tsubgraph cluster_%04x {\n", b.start)
+		fmt.Fprintf(w, "\t\tlabel=%q;\n", name)
+
+		for _, b := range blocks {
+			writeDotBlock(w, "\t\t", rom, labels, b)
+		}
+
+		fmt.Fprintln(w, "\t}")
+	}
+
+	for _, b := range graph.sortedBlocks() {
+		for _, e := range b.edges {
+			fmt.Fprintf(w, "\tb%04x -> b%04x [label=%q, style=%s];\n",
+				b.start, e.to, e.kind, edgeStyles[e.kind])
+		}
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+func writeDotBlock(w io.Writer, indent string, rom ROM, labels map[uint16]string, b *block) {
+	var sb strings.Builder
+
+	if label, ok := labels[b.start]; ok {
+		fmt.Fprintf(&sb, "%s:\\l", label)
+	}
+
+	for pc := b.start; pc <= b.end; pc += 2 {
+		instr := decode(rom.word(pc))
+
+		dis := instr.String()
+		if label, ok := labels[instr.ref]; ok && instr.hasRef {
+			dis += " ; " + label
+		}
+
+		dis = strings.ReplaceAll(dis, `\`, `\\`)
+		dis = strings.ReplaceAll(dis, `"`, `\"`)
+
+		fmt.Fprintf(&sb, "%04x  %s\\l", pc, dis)
+	}
+
+	fmt.Fprintf(w, "%sb%04x [label=\"%s\"];\n", indent, b.start, sb.String())
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func writeJSON(w io.Writer, lines []line) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(lines)
+}
+
+// writeOcto writes the listing as Octo source. Every byte of the ROM is emitted
+// in order, starting at `main` so Octo doesn't insert a jump, and assembling
+// the output results in the original ROM.
+func writeOcto(w io.Writer, lines []line) {
+	// only labels inside the ROM are defined, other targets stay addresses.
+	labels := make(map[uint16]string)
+
+	for _, l := range lines {
+		if l.Label != "" {
+			labels[l.Addr] = l.Label
+		}
+	}
+
+	fmt.Fprintln(w, ": main")
+
+	for _, l := range lines {
+		if l.Label != "" {
+			fmt.Fprintf(w, ": %s\n", l.Label)
+		}
+
+		var src string
+
+		if l.Kind == "code" {
+			src = octo(uint16(l.Bytes[0])<<8|uint16(l.Bytes[1]), labels)
+		}
+
+		if src == "" {
+			// data, or an instruction Octo doesn't know about.
+			bs := make([]string, 0, len(l.Bytes))
+			for _, b := range l.Bytes {
+				bs = append(bs, fmt.Sprintf("0x%02X", b))
+			}
+
+			src = strings.Join(bs, " ")
+		}
+
+		if l.Kind == "data" && l.Comment != "" {
+			src += " # " + l.Comment
+		}
+
+		fmt.Fprintf(w, "\t%s\n", src)
+	}
+}
+
+// octo translates a single instruction into Octo syntax. Addresses are
+// replaced by their label, if they have one.
+func octo(instr uint16, labels map[uint16]string) string {
+	m := instr >> 12
+	x := (instr & 0x0F00) >> 8
+	y := (instr & 0x00F0) >> 4
+	n := instr & 0x000F
+	kk := instr & 0x00FF
+	addr := instr & 0x0FFF
+
+	vx := fmt.Sprintf("v%x", x)
+	vy := fmt.Sprintf("v%x", y)
+	byt := fmt.Sprintf("0x%02X", kk)
+
+	target := fmt.Sprintf("0x%03X", addr)
+	if label, ok := labels[addr]; ok {
+		target = label
+	}
+
+	// Octo doesn't have explicit skip instructions, instead `if <cond> then`
+	// skips the next instruction if <cond> is false.
+	switch m {
+	case 0x0:
+		switch addr {
+		case 0x0E0:
+			// 00E0: CLS
+			return "clear"
+		case 0x0EE:
+			// 00EE: RET
+			return "return"
+		}
+	case 0x1:
+		// 1nnn: JP addr
+		return "jump " + target
+	case 0x2:
+		// 2nnn: CALL addr
+		if _, ok := labels[addr]; ok {
+			return target
+		}
+
+		return ":call " + target
+	case 0x3:
+		// 3xkk: SE Vx, byte
+		return fmt.Sprintf("if %s != %s then", vx, byt)
+	case 0x4:
+		// 4xkk: SNE Vx, byte
+		return fmt.Sprintf("if %s == %s then", vx, byt)
+	case 0x5:
+		if n == 0x0 {
+			// 5xy0: SE Vx, Vy
+			return fmt.Sprintf("if %s != %s then", vx, vy)
+		}
+	case 0x6:
+		// 6xkk: LD Vx, byte
+		return fmt.Sprintf("%s := %s", vx, byt)
+	case 0x7:
+		// 7xkk: ADD Vx, byte
+		return fmt.Sprintf("%s += %s", vx, byt)
+	case 0x8:
+		ops := map[uint16]string{
+			0x0: ":=", 0x1: "|=", 0x2: "&=", 0x3: "^=", 0x4: "+=",
+			0x5: "-=", 0x6: ">>=", 0x7: "=-", 0xE: "<<=",
+		}
+
+		if op, ok := ops[n]; ok {
+			return fmt.Sprintf("%s %s %s", vx, op, vy)
+		}
+	case 0x9:
+		if n == 0x0 {
+			// 9xy0: SNE Vx, Vy
+			return fmt.Sprintf("if %s == %s then", vx, vy)
+		}
+	case 0xA:
+		// Annn: LD I, addr
+		return "i := " + target
+	case 0xB:
+		// Bnnn: JP V0, addr
+		return "jump0 " + target
+	case 0xC:
+		// Cxkk: RND Vx, byte
+		return fmt.Sprintf("%s := random %s", vx, byt)
+	case 0xD:
+		// Dxyn: DRW Vx, Vy, nibble
+		return fmt.Sprintf("sprite %s %s %d", vx, vy, n)
+	case 0xE:
+		switch kk {
+		case 0x9E:
+			// Ex9E: SKP Vx
+			return fmt.Sprintf("if %s -key then", vx)
+		case 0xA1:
+			// ExA1: SKNP Vx
+			return fmt.Sprintf("if %s key then", vx)
+		}
+	case 0xF:
+		switch kk {
+		case 0x07:
+			// Fx07: LD Vx, DT
+			return vx + " := delay"
+		case 0x0A:
+			// Fx0A: LD Vx, K
+			return vx + " := key"
+		case 0x15:
+			// Fx15: LD DT, Vx
+			return "delay := " + vx
+		case 0x18:
+			// Fx18: LD ST, Vx
+			return "buzzer := " + vx
+		case 0x1E:
+			// Fx1E: ADD I, Vx
+			return "i += " + vx
+		case 0x29:
+			// Fx29: LD F, Vx
+			return "i := hex " + vx
+		case 0x33:
+			// Fx33: LD B, Vx
+			return "bcd " + vx
+		case 0x55:
+			// Fx55: LD [I], Vx
+			return "save " + vx
+		case 0x65:
+			// Fx65: LD Vx, [I]
+			return "load " + vx
+		}
+	}
+
+	return ""
+}
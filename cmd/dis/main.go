@@ -1,13 +1,19 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/log"
 )
 
-type ROM []uint16
+// ROMs are loaded at 0x200, all addresses below are absolute.
+const start = 0x200
+
+type ROM []uint8
 
 func load(filename string) (ROM, error) {
 	bs, err := os.ReadFile(filename)
@@ -15,17 +21,29 @@ func load(filename string) (ROM, error) {
 		return nil, err
 	}
 
-	if len(bs)%2 != 0 {
-		return nil, err
-	}
+	return ROM(bs), nil
+}
 
-	rom := ROM{}
+// end returns the first address after the ROM.
+func (rom ROM) end() uint16 {
+	return start + uint16(len(rom))
+}
 
-	for i := 0; i < len(bs); i += 2 {
-		rom = append(rom, uint16(bs[i])<<8|uint16(bs[i+1]))
+// byteAt returns the byte at absolute address `addr`.
+func (rom ROM) byteAt(addr uint16) uint8 {
+	return rom[addr-start]
+}
+
+// word returns the big-endian word at absolute address `addr`. If only a
+// single byte is left, it is returned in the high byte.
+func (rom ROM) word(addr uint16) uint16 {
+	hi := uint16(rom.byteAt(addr))
+
+	if addr+1 >= rom.end() {
+		return hi << 8
 	}
 
-	return rom, nil
+	return hi<<8 | uint16(rom.byteAt(addr+1))
 }
 
 func generateLabels(rom ROM) map[uint16]string {
@@ -33,7 +51,8 @@ func generateLabels(rom ROM) map[uint16]string {
 
 	count := 0
 
-	for _, instr := range rom {
+	for pc := uint16(start); pc+1 < rom.end(); pc += 2 {
+		instr := rom.word(pc)
 		m := instr >> 12
 		addr := instr & 0x0FFF
 
@@ -56,155 +75,273 @@ func generateLabels(rom ROM) map[uint16]string {
 	return labels
 }
 
-func disassemble(rom ROM, labels map[uint16]string) {
-	for i, instr := range rom {
-		m := instr >> 12
-		x := (instr & 0x0F00) >> 8
-		y := (instr & 0x00F0) >> 4
-		n := instr & 0x000F
-		kk := instr & 0x00FF
-		addr := instr & 0x0FFF
+// instruction is a single decoded instruction. `ref` is the address the
+// instruction jumps to, calls or loads, if `hasRef` is set.
+type instruction struct {
+	op       string
+	operands []string
+	ref      uint16
+	hasRef   bool
+}
 
-		if label, ok := labels[uint16(i+0x200)]; ok {
-			fmt.Printf("%s:\n", label)
-		}
+// decode splits a single instruction into its mnemonic and operands. Unknown
+// instructions have an empty mnemonic.
+func decode(instr uint16) instruction {
+	m := instr >> 12
+	x := (instr & 0x0F00) >> 8
+	y := (instr & 0x00F0) >> 4
+	n := instr & 0x000F
+	kk := instr & 0x00FF
+	addr := instr & 0x0FFF
 
-		dis := fmt.Sprintf("%04x\t%04x\t", i+0x200, instr)
+	vx := fmt.Sprintf("V%x", x)
+	vy := fmt.Sprintf("V%x", y)
+	nnn := fmt.Sprintf("%04x", addr)
+	byt := fmt.Sprintf("%02x", kk)
 
-		switch m {
+	op := func(op string, operands ...string) instruction {
+		return instruction{op: op, operands: operands}
+	}
+	ref := func(op string, operands ...string) instruction {
+		return instruction{op: op, operands: operands, ref: addr, hasRef: true}
+	}
+
+	switch m {
+	case 0x0:
+		switch addr {
+		case 0x0E0:
+			// 00E0: CLS
+			return op("CLS")
+		case 0x0EE:
+			// 00EE: RET
+			return op("RET")
+		}
+	case 0x1:
+		// 1nnn: JP addr
+		return ref("JP", nnn)
+	case 0x2:
+		// 2nnn: CALL addr
+		return ref("CALL", nnn)
+	case 0x3:
+		// 3xkk: SE Vx, byte
+		return op("SE", vx, byt)
+	case 0x4:
+		// 4xkk: SNE Vx, byte
+		return op("SNE", vx, byt)
+	case 0x5:
+		if n == 0x0 {
+			// 5xy0: SE Vx, Vy
+			return op("SE", vx, vy)
+		}
+	case 0x6:
+		// 6xkk: LD Vx, byte
+		return op("LD", vx, byt)
+	case 0x7:
+		// 7xkk: ADD Vx, byte
+		return op("ADD", vx, byt)
+	case 0x8:
+		switch n {
 		case 0x0:
-			switch addr {
-			case 0x0E0:
-				// 00E0: CLS
-				dis += "CLS"
-			case 0x0EE:
-				// 00EE: RET
-				dis += "RET"
-			}
+			// 8xy0: LD Vx, Vy
+			return op("LD", vx, vy)
 		case 0x1:
-			// 1nnn: JP addr
-			dis += fmt.Sprintf("JP   %04x", addr)
-
-			if label, ok := labels[addr]; ok {
-				dis += fmt.Sprintf(" ; %s", label)
-			}
+			// 8xy1: OR Vx, Vy
+			return op("OR", vx, vy)
 		case 0x2:
-			// 2nnn: CALL addr
-			dis += fmt.Sprintf("CALL %04x", addr)
-
-			if label, ok := labels[addr]; ok {
-				dis += fmt.Sprintf(" ; %s", label)
-			}
+			// 8xy2: AND Vx, Vy
+			return op("AND", vx, vy)
 		case 0x3:
-			// 3xkk: SE Vx, byte
-			dis += fmt.Sprintf("SE   V%01x, %02x", x, kk)
+			// 8xy3: XOR Vx, Vy
+			return op("XOR", vx, vy)
 		case 0x4:
-			// 4xkk: SNE Vx, byte
-			dis += fmt.Sprintf("SNE  V%01x, %02x", x, kk)
+			// 8xy4: ADD Vx, Vy
+			return op("ADD", vx, vy)
 		case 0x5:
-			if n == 0x0 {
-				// 5xy0: SE Vx, Vy
-				dis += fmt.Sprintf("SE   V%01x, V%01x", x, y)
-			}
+			// 8xy5: SUB Vx, Vy
+			return op("SUB", vx, vy)
 		case 0x6:
-			// 6xkk: LD Vx, byte
-			dis += fmt.Sprintf("LD   V%01x, %02x", x, kk)
+			// 8xy6: SHR Vx {, Vy}
+			return op("SHR", vx, vy)
 		case 0x7:
-			// 7xkk: ADD Vx, byte
-			dis += fmt.Sprintf("ADD  V%01x, %02x", x, kk)
-		case 0x8:
-			switch n {
-			case 0x0:
-				// 8xy0: LD Vx, Vy
-				dis += fmt.Sprintf("LD   V%x, V%x", x, y)
-			case 0x1:
-				// 8xy1: OR Vx, Vy
-				dis += fmt.Sprintf("OR   V%x, V%x", x, y)
-			case 0x2:
-				// 8xy2: AND Vx, Vy
-				dis += fmt.Sprintf("AND  V%x, V%x", x, y)
-			case 0x3:
-				// 8xy3: XOR Vx, Vy
-				dis += fmt.Sprintf("XOR  V%x, V%x", x, y)
-			case 0x4:
-				// 8xy4: ADD Vx, Vy
-				dis += fmt.Sprintf("ADD  V%x, V%x", x, y)
-			case 0x5:
-				// 8xy5: SUB Vx, Vy
-				dis += fmt.Sprintf("SUB  V%x, V%x", x, y)
-			case 0x6:
-				// 8xy6: SHR Vx {, Vy}
-				dis += fmt.Sprintf("SHR  V%x {, V%x}", x, y)
-			case 0x7:
-				// 8xy7: SUBN Vx, Vy
-				dis += fmt.Sprintf("SUBN V%x, V%x", x, y)
-			case 0xE:
-				// 8xyE: SHL Vx {, Vy}
-				dis += fmt.Sprintf("SHL  V%x {, V%x}", x, y)
-			}
-		case 0x9:
-			if n == 0x0 {
-				// 9xy0: SNE Vx, Vy
-				dis += fmt.Sprintf("SNE  V%x, V%x", x, y)
-			}
-		case 0xA:
-			// Annn: LD I, addr
-			dis += fmt.Sprintf("LD   I, %04x", addr)
-		case 0xB:
-			// Bnnn: JP V0, addr
-			dis += fmt.Sprintf("JP   V0, %04x", addr)
+			// 8xy7: SUBN Vx, Vy
+			return op("SUBN", vx, vy)
+		case 0xE:
+			// 8xyE: SHL Vx {, Vy}
+			return op("SHL", vx, vy)
+		}
+	case 0x9:
+		if n == 0x0 {
+			// 9xy0: SNE Vx, Vy
+			return op("SNE", vx, vy)
+		}
+	case 0xA:
+		// Annn: LD I, addr
+		return ref("LD", "I", nnn)
+	case 0xB:
+		// Bnnn: JP V0, addr
+		return ref("JP", "V0", nnn)
+	case 0xC:
+		// Cxkk: RND Vx, byte
+		return op("RND", vx, byt)
+	case 0xD:
+		// Dxyn: DRW Vx, Vy, nibble
+		return op("DRW", vx, vy, fmt.Sprintf("%x", n))
+	case 0xE:
+		switch kk {
+		case 0x9E:
+			// Ex9E: SKP Vx
+			return op("SKP", vx)
+		case 0xA1:
+			// ExA1: SKNP Vx
+			return op("SKNP", vx)
+		}
+	case 0xF:
+		switch kk {
+		case 0x07:
+			// Fx07: LD Vx, DT
+			return op("LD", vx, "DT")
+		case 0x0A:
+			// Fx0A: LD Vx, K
+			return op("LD", vx, "K")
+		case 0x15:
+			// Fx15: LD DT, Vx
+			return op("LD", "DT", vx)
+		case 0x18:
+			// Fx18: LD ST, Vx
+			return op("LD", "ST", vx)
+		case 0x1E:
+			// Fx1E: ADD I, Vx
+			return op("ADD", "I", vx)
+		case 0x29:
+			// Fx29: LD F, Vx
+			return op("LD", "F", vx)
+		case 0x33:
+			// Fx33: LD B, Vx
+			return op("LD", "B", vx)
+		case 0x55:
+			// Fx55: LD [I], Vx
+			return op("LD", "[I]", vx)
+		case 0x65:
+			// Fx65: LD Vx, [I]
+			return op("LD", vx, "[I]")
+		}
+	}
+
+	return instruction{}
+}
+
+// String formats the instruction the way the text listing shows it.
+func (in instruction) String() string {
+	switch {
+	case len(in.operands) == 0:
+		return in.op
+	case in.op == "SHR" || in.op == "SHL":
+		// the second operand is ignored in the original interpreter.
+		return fmt.Sprintf("%-4s %s {, %s}", in.op, in.operands[0], in.operands[1])
+	default:
+		return fmt.Sprintf("%-4s %s", in.op, strings.Join(in.operands, ", "))
+	}
+}
+
+// line is a single line of the listing: either an instruction or a byte of
+// data.
+type line struct {
+	Addr     uint16   `json:"addr"`
+	Bytes    []int    `json:"bytes"`
+	Mnemonic string   `json:"mnemonic,omitempty"`
+	Operands []string `json:"operands,omitempty"`
+	Label    string   `json:"label,omitempty"`
+	Comment  string   `json:"comment,omitempty"`
+	Kind     string   `json:"kind"`
 
-			if label, ok := labels[addr]; ok {
-				dis += fmt.Sprintf(" ; %s", label)
+	instr instruction
+}
+
+// isInstruction reports whether the listing decodes an instruction at `pc`,
+// rather than a single byte of data.
+func isInstruction(rom ROM, labels map[uint16]string, data map[uint16]bool, pc uint16) bool {
+	if data[pc] {
+		return false
+	}
+
+	// a single byte that doesn't form a complete instruction, either at
+	// the end of the ROM or right before a sprite or label.
+	if _, ok := labels[pc+1]; ok || pc+1 >= rom.end() || data[pc+1] {
+		return false
+	}
+
+	return true
+}
+
+// listing splits the ROM into lines of code and data, the same way for all
+// output formats.
+func listing(rom ROM, labels map[uint16]string, data map[uint16]bool) []line {
+	var lines []line
+
+	for pc := uint16(start); pc < rom.end(); {
+		l := line{
+			Addr:  pc,
+			Label: labels[pc],
+		}
+
+		if !isInstruction(rom, labels, data, pc) {
+			b := rom.byteAt(pc)
+
+			l.Bytes = []int{int(b)}
+			l.Kind = "data"
+
+			if data[pc] {
+				l.Comment = spriteRow(b)
 			}
-		case 0xC:
-			// Cxkk: RND Vx, byte
-			dis += fmt.Sprintf("RND  V%x, %02x", x, kk)
-		case 0xD:
-			// Dxyn: DRW Vx, Vy, nibble
-			dis += fmt.Sprintf("DRW  V%x, V%x, %x", x, y, n)
-		case 0xE:
-			switch kk {
-			case 0x9E:
-				// Ex9E: SKP Vx
-				dis += fmt.Sprintf("SKP  V%x", x)
-			case 0xA1:
-				// ExA1: SKNP Vx
-				dis += fmt.Sprintf("SKNP V%x", x)
+
+			lines = append(lines, l)
+			pc++
+
+			continue
+		}
+
+		instr := rom.word(pc)
+
+		l.Bytes = []int{int(instr >> 8), int(instr & 0xFF)}
+		l.Kind = "code"
+		l.instr = decode(instr)
+		l.Mnemonic = l.instr.op
+		l.Operands = l.instr.operands
+
+		if l.instr.hasRef {
+			l.Comment = labels[l.instr.ref]
+		}
+
+		lines = append(lines, l)
+		pc += 2
+	}
+
+	return lines
+}
+
+func disassemble(w io.Writer, lines []line) {
+	for _, l := range lines {
+		if l.Label != "" {
+			fmt.Fprintf(w, "%s:\n", l.Label)
+		}
+
+		var dis string
+
+		if l.Kind == "data" {
+			dis = fmt.Sprintf("%04x\t%02x", l.Addr, l.Bytes[0])
+
+			if l.Comment != "" {
+				dis += "\t" + l.Comment
 			}
-		case 0xF:
-			switch kk {
-			case 0x07:
-				// Fx07: LD Vx, DT
-				dis += fmt.Sprintf("LD   V%x, DT", x)
-			case 0x0A:
-				// Fx0A: LD Vx, K
-				dis += fmt.Sprintf("LD   V%x, K", x)
-			case 0x15:
-				// Fx15: LD DT, Vx
-				dis += fmt.Sprintf("LD   DT, V%x", x)
-			case 0x18:
-				// Fx18: LD ST, Vx
-				dis += fmt.Sprintf("LD   ST, V%x", x)
-			case 0x1E:
-				// Fx1E: ADD I, Vx
-				dis += fmt.Sprintf("ADD  I, V%x", x)
-			case 0x29:
-				// Fx29: LD F, Vx
-				dis += fmt.Sprintf("LD   F, V%x", x)
-			case 0x33:
-				// Fx33: LD B, Vx
-				dis += fmt.Sprintf("LD   B, V%x", x)
-			case 0x55:
-				// Fx55: LD [I], Vx
-				dis += fmt.Sprintf("LD   [I], V%x", x)
-			case 0x65:
-				// Fx65: LD Vx, [I]
-				dis += fmt.Sprintf("LD   V%x, [I]", x)
+		} else {
+			dis = fmt.Sprintf("%04x\t%02x%02x\t%s", l.Addr, l.Bytes[0], l.Bytes[1], l.instr)
+
+			if l.Comment != "" {
+				dis += " ; " + l.Comment
 			}
 		}
 
-		fmt.Println(dis)
+		fmt.Fprintln(w, dis)
 	}
 }
 
@@ -212,12 +349,17 @@ func main() {
 	logger := log.New(os.Stdout)
 	logger.SetReportTimestamp(true)
 
-	if len(os.Args) < 2 {
-		logger.Errorf("Usage: %s <source.ch8>", os.Args[0])
+	format := flag.String("format", "text", "output format (text, json, octo, dot)")
+	withXrefs := flag.Bool("xref", true, "append a cross-reference section to the text listing")
+	withAnalysis := flag.Bool("analyze", false, "detect quirk-dependent code and recommend a platform")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		logger.Errorf("Usage: %s [-format text|json|octo|dot] [-analyze] <source.ch8>", os.Args[0])
 		os.Exit(1)
 	}
 
-	filename := os.Args[1]
+	filename := flag.Arg(0)
 
 	rom, err := load(filename)
 	if err != nil {
@@ -226,6 +368,34 @@ func main() {
 	}
 
 	labels := generateLabels(rom)
+	sprites := findSprites(rom, labels)
+
+	data := spriteBytes(sprites)
+
+	if *withAnalysis {
+		writeAnalysis(os.Stdout, rom, analyze(rom, buildCFG(rom, labels, data)))
+
+		return
+	}
 
-	disassemble(rom, labels)
+	switch *format {
+	case "text":
+		disassemble(os.Stdout, listing(rom, labels, data))
+
+		if *withXrefs {
+			writeXrefs(os.Stdout, labels, findXrefs(rom, labels, data))
+		}
+	case "json":
+		if err := writeJSON(os.Stdout, listing(rom, labels, data)); err != nil {
+			logger.Errorf("failed to write json: %v", err)
+			os.Exit(1)
+		}
+	case "octo":
+		writeOcto(os.Stdout, listing(rom, labels, data))
+	case "dot":
+		writeDot(os.Stdout, rom, labels, buildCFG(rom, labels, data))
+	default:
+		logger.Errorf("unknown format: %s (supported: text, json, octo, dot)", *format)
+		os.Exit(1)
+	}
 }
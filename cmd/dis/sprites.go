@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// findSprites scans the ROM for sprites: byte ranges that are loaded into I
+// with `LD I, addr` and then drawn with `DRW`. It returns the height of each
+// sprite keyed by its address, and adds a `spriteNN` label for each sprite
+// that doesn't already have one.
+func findSprites(rom ROM, labels map[uint16]string) map[uint16]uint16 {
+	sprites := map[uint16]uint16{}
+
+	// the value of I, if it's known at this point of the linear scan.
+	var (
+		i     uint16
+		known bool
+	)
+
+	for pc := uint16(start); pc+1 < rom.end(); pc += 2 {
+		// we can't know the value of I if this address is the target of a
+		// jump or call.
+		if _, ok := labels[pc]; ok {
+			known = false
+		}
+
+		instr := rom.word(pc)
+		m := instr >> 12
+		n := instr & 0x000F
+		kk := instr & 0x00FF
+		addr := instr & 0x0FFF
+
+		switch m {
+		case 0x0:
+			if addr == 0x0EE {
+				// 00EE: RET
+				known = false
+			}
+		case 0x1, 0xB:
+			// 1nnn: JP addr
+			// Bnnn: JP V0, addr
+			known = false
+		case 0xA:
+			// Annn: LD I, addr
+			i, known = addr, true
+		case 0xD:
+			// Dxyn: DRW Vx, Vy, nibble
+			if !known || n == 0 || i < start || i+n > rom.end() {
+				break
+			}
+
+			if n > sprites[i] {
+				sprites[i] = n
+			}
+		case 0xF:
+			switch kk {
+			case 0x1E, 0x29:
+				// Fx1E: ADD I, Vx
+				// Fx29: LD F, Vx
+				known = false
+			}
+		}
+	}
+
+	addrs := make([]uint16, 0, len(sprites))
+	for addr := range sprites {
+		addrs = append(addrs, addr)
+	}
+
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	for count, addr := range addrs {
+		if _, ok := labels[addr]; !ok {
+			labels[addr] = fmt.Sprintf("sprite%02d", count)
+		}
+	}
+
+	return sprites
+}
+
+// spriteBytes returns the set of addresses that are part of a sprite.
+func spriteBytes(sprites map[uint16]uint16) map[uint16]bool {
+	data := map[uint16]bool{}
+
+	for addr, height := range sprites {
+		for i := uint16(0); i < height; i++ {
+			data[addr+i] = true
+		}
+	}
+
+	return data
+}
+
+// spriteRow renders a single sprite byte as ASCII art, e.g. `##..##..`.
+func spriteRow(b uint8) string {
+	var sb strings.Builder
+
+	for bit := 7; bit >= 0; bit-- {
+		if (b>>bit)&0x01 == 1 {
+			sb.WriteRune('#')
+		} else {
+			sb.WriteRune('.')
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type xrefKind string
+
+const (
+	xrefJump xrefKind = "jump"
+	xrefCall xrefKind = "call"
+	xrefLoad xrefKind = "load"
+)
+
+type xref struct {
+	from uint16
+	kind xrefKind
+}
+
+// findXrefs collects, for every address that is jumped to, called or loaded
+// into I, the instructions that reference it.
+func findXrefs(rom ROM, labels map[uint16]string, data map[uint16]bool) map[uint16][]xref {
+	xrefs := map[uint16][]xref{}
+
+	for pc := uint16(start); pc < rom.end(); {
+		if !isInstruction(rom, labels, data, pc) {
+			pc++
+
+			continue
+		}
+
+		instr := rom.word(pc)
+		addr := instr & 0x0FFF
+
+		switch instr >> 12 {
+		case 0x1, 0xB:
+			// 1nnn: JP addr
+			// Bnnn: JP V0, addr
+			xrefs[addr] = append(xrefs[addr], xref{pc, xrefJump})
+		case 0x2:
+			// 2nnn: CALL addr
+			xrefs[addr] = append(xrefs[addr], xref{pc, xrefCall})
+		case 0xA:
+			// Annn: LD I, addr
+			xrefs[addr] = append(xrefs[addr], xref{pc, xrefLoad})
+		}
+
+		pc += 2
+	}
+
+	// labels without any references are listed as well, so it's obvious
+	// they're unused.
+	for addr := range labels {
+		if _, ok := xrefs[addr]; !ok {
+			xrefs[addr] = nil
+		}
+	}
+
+	return xrefs
+}
+
+// writeXrefs writes the cross-reference section: one line per target, with the
+// label (if any) and the instructions that reference it.
+func writeXrefs(w io.Writer, labels map[uint16]string, xrefs map[uint16][]xref) {
+	addrs := make([]uint16, 0, len(xrefs))
+	for addr := range xrefs {
+		addrs = append(addrs, addr)
+	}
+
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "xref:")
+
+	for _, addr := range addrs {
+		refs := make([]string, 0, len(xrefs[addr]))
+
+		for _, ref := range xrefs[addr] {
+			refs = append(refs, fmt.Sprintf("%s %04x", ref.kind, ref.from))
+		}
+
+		fmt.Fprintf(w, "%04x\t%s\t%s\n", addr, labels[addr], strings.Join(refs, ", "))
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/charmbracelet/log"
 	"github.com/corani/chip-8/internal/chip8"
+	"github.com/corani/chip-8/internal/display"
+	"github.com/corani/chip-8/internal/keymap"
 )
 
 type gameState struct {
@@ -19,7 +21,10 @@ type gameState struct {
 	chip8  *chip8.Chip8
 	logger *log.Logger
 	time   time.Time
-	grid   [64][32]uint8
+	grid   [64][32]uint8 // brightness of the pixels
+	filter display.Filter
+	keymap keymap.Keymap
+	bg, fg string // colors of the canvas
 }
 
 func (state *gameState) init() {
@@ -30,12 +35,72 @@ func (state *gameState) onRun(romName string, romData []byte) {
 	state.log("name: %v", romName)
 	state.log("size: %v", len(romData))
 
-	state.chip8 = chip8.New(nil, romName, romData)
+	chip8, err := chip8.New(nil, romName, romData)
+	if err != nil {
+		state.log("failed to load rom: %v", err)
+
+		return
+	}
+
+	state.chip8 = chip8
+	state.chip8.SetFilter(state.filter)
 	state.time = time.Now()
 
 	state.step()
 }
 
+// browserKeys are the names of the keys in a keymap, for the values of
+// `KeyboardEvent.key` that aren't the character typed.
+var browserKeys = map[string]string{
+	"ArrowUp": "up", "ArrowDown": "down", "ArrowLeft": "left", "ArrowRight": "right",
+	" ": "space", "Enter": "enter",
+}
+
+// onKey handles the keys in the keymap and the hotkeys, and reports whether
+// the key was handled.
+func (state *gameState) onKey(key string, down bool) bool {
+	if state.chip8 == nil {
+		return false
+	}
+
+	name, ok := browserKeys[key]
+	if !ok {
+		name = key
+	}
+
+	if code, ok := state.keymap.Key(name); ok {
+		if down {
+			state.chip8.KeyDown(code)
+		} else {
+			state.chip8.KeyUp(code)
+		}
+
+		return true
+	}
+
+	switch key {
+	case "Tab":
+		// fast-forward while the key is held.
+		state.chip8.SetFastForward(down)
+	case "F2":
+		if down {
+			state.chip8.TogglePause()
+		}
+	case "F3":
+		if down {
+			state.chip8.Step()
+		}
+	case "F4":
+		if down {
+			state.chip8.ToggleSlowMotion()
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
 func (state *gameState) step() {
 	if state.chip8 == nil {
 		return
@@ -62,15 +127,11 @@ func (state *gameState) update() {
 
 	state.chip8.Tick(dt)
 
-	fb := state.chip8.Framebuffer()
+	screen := state.chip8.Screen()
 
 	for y := 0; y < 32; y++ {
 		for x := 0; x < 64; x++ {
-			if fb[x][y] == 0 {
-				state.grid[x][y] = 0
-			} else {
-				state.grid[x][y] = 1
-			}
+			state.grid[x][y] = screen[x][y]
 		}
 	}
 }
@@ -95,20 +156,37 @@ func (state *gameState) draw() {
 	offsetY := (h - cellHeight*32) / 2
 
 	// clear the canvas
-	ctx.Set("fillStyle", "#f4f4f4")
+	ctx.Set("fillStyle", state.bg)
 	ctx.Call("fillRect", 0, 0, w, h)
 
-	// draw the grid
-	ctx.Set("fillStyle", "#1818baba")
+	// draw the grid, fading pixels by their brightness.
+	ctx.Set("fillStyle", state.fg)
+
+	alpha := uint8(255)
+
 	for y := 0; y < 32; y++ {
 		for x := 0; x < 64; x++ {
 			if state.grid[x][y] == 0 {
 				continue
 			}
 
+			if state.grid[x][y] != alpha {
+				alpha = state.grid[x][y]
+				ctx.Set("globalAlpha", float64(alpha)/255)
+			}
+
 			ctx.Call("fillRect", x*cellSize+offsetX, y*cellSize+offsetY, cellSize, cellSize)
 		}
 	}
+
+	ctx.Set("globalAlpha", 1)
+
+	// draw the speed indicator
+	if mode := state.chip8.Mode(); mode != "" {
+		ctx.Set("font", "16px monospace")
+		ctx.Set("textBaseline", "top")
+		ctx.Call("fillText", mode, offsetX+4, offsetY+4)
+	}
 }
 
 func (state *gameState) log(format string, args ...any) {
@@ -6,6 +6,10 @@ import (
 	"strconv"
 	"strings"
 	"syscall/js"
+
+	"github.com/corani/chip-8/internal/display"
+	"github.com/corani/chip-8/internal/keymap"
+	"github.com/corani/chip-8/internal/palette"
 )
 
 const (
@@ -16,11 +20,54 @@ const (
 func main() {
 	doc := js.Global().Get("document")
 
-	// TODO(daniel): handle keyboard
 	// TODO(daniel): handle sound
 	state := &gameState{
 		canvas:  doc.Call("getElementById", "gameCanvas"),
 		console: js.Global().Get("console"),
+		bg:      "#f4f4f4",
+		fg:      "#1818baba",
+		keymap:  keymap.Default(),
+	}
+
+	// the keymap is chosen in the URL too, e.g. `?keymap=azerty,space=5`.
+	if spec := queryParam("keymap"); spec != "" {
+		if m, err := keymap.Parse(spec); err != nil {
+			state.log("invalid keymap: %v", err)
+		} else {
+			state.keymap = keymap.Resolve(nil, m)
+		}
+	}
+
+	// the theme is chosen in the URL too, e.g. `?theme=amber`.
+	if theme := queryParam("theme"); theme != "" {
+		if p, err := palette.Parse(theme); err != nil {
+			state.log("invalid theme: %v", err)
+		} else {
+			state.bg = palette.Hex(p.Background())
+			state.fg = palette.Hex(p.Foreground())
+		}
+	}
+
+	// flicker reduction is configured in the URL, e.g. `?decay=4&blend=1`.
+	decay, _ := strconv.Atoi(queryParam("decay"))
+	state.filter = display.Filter{
+		VBlank: queryParam("vblank") != "",
+		Blend:  queryParam("blend") != "",
+		Decay:  decay,
+	}
+
+	for _, event := range []string{"keydown", "keyup"} {
+		down := event == "keydown"
+
+		doc.Call("addEventListener", event, js.FuncOf(
+			func(this js.Value, args []js.Value) any {
+				if state.onKey(args[0].Get("key").String(), down) {
+					args[0].Call("preventDefault")
+				}
+
+				return nil
+			},
+		))
 	}
 
 	js.Global().Set("runGame", js.FuncOf(
@@ -49,3 +96,15 @@ func main() {
 	// WASM main is expected to block indefinitely.
 	select {}
 }
+
+// queryParam returns the parameter `name` from the query string of the page.
+func queryParam(name string) string {
+	params := js.Global().Get("URLSearchParams").New(js.Global().Get("location").Get("search"))
+
+	value := params.Call("get", name)
+	if value.IsNull() {
+		return ""
+	}
+
+	return value.String()
+}
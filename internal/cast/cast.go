@@ -0,0 +1,100 @@
+package cast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Create starts recording an asciinema v2 `.cast` file at `path`, for a
+// terminal of the given size.
+func Create(path string, width, height int, title string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		file:  f,
+		w:     bufio.NewWriter(f),
+		start: time.Now(),
+	}
+
+	header := map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": r.start.Unix(),
+		"title":     title,
+		"env":       map[string]string{"TERM": os.Getenv("TERM"), "SHELL": os.Getenv("SHELL")},
+	}
+
+	if err := r.writeLine(header); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Recorder writes the output of a terminal session as asciinema events. It's
+// safe to use from multiple goroutines.
+type Recorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	w     *bufio.Writer
+	start time.Time
+}
+
+// Write records `p` as output at the current time.
+func (r *Recorder) Write(p []byte) (int, error) {
+	if err := r.event("o", string(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Resize records a change of the terminal size.
+func (r *Recorder) Resize(width, height int) error {
+	return r.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// Close flushes the recording and closes the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.w.Flush(); err != nil {
+		r.file.Close()
+
+		return err
+	}
+
+	return r.file.Close()
+}
+
+// event writes an event line: `[time, code, data]`.
+func (r *Recorder) event(code, data string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.writeLine([]any{time.Since(r.start).Seconds(), code, data})
+}
+
+func (r *Recorder) writeLine(v any) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.w.Write(bs); err != nil {
+		return err
+	}
+
+	return r.w.WriteByte('\n')
+}
@@ -1,6 +1,9 @@
 package chip8
 
 import (
+	"fmt"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/log"
@@ -8,11 +11,18 @@ import (
 	"github.com/corani/chip-8/internal/display"
 	"github.com/corani/chip-8/internal/keyboard"
 	"github.com/corani/chip-8/internal/memory"
+	"github.com/corani/chip-8/internal/quirks"
 	"github.com/corani/chip-8/internal/sound"
 	"github.com/corani/chip-8/internal/timer"
 )
 
-func New(logger *log.Logger, romfile string, romdata []uint8) *Chip8 {
+// romStart is the address where ROMs are loaded.
+const romStart = 0x200
+
+// frame is the duration of a single 60Hz frame.
+const frame = time.Second / 60
+
+func New(logger *log.Logger, romfile string, romdata []uint8) (*Chip8, error) {
 	soundTimer := timer.New()
 
 	chip8 := &Chip8{
@@ -22,35 +32,224 @@ func New(logger *log.Logger, romfile string, romdata []uint8) *Chip8 {
 		keyboard: keyboard.New(),
 		sound:    sound.New(soundTimer),
 		delay:    timer.New(),
+		turbo:    4,
+		slow:     0.25,
 	}
 
-	chip8.memory.Load(0x000, digitSprites())
-	chip8.memory.Load(0x200, romdata)
-
 	chip8.cpu = cpu.New(logger, chip8.memory, chip8.display, chip8.keyboard, chip8.delay, soundTimer)
 
-	return chip8
+	if err := chip8.load(romfile, romdata); err != nil {
+		return nil, err
+	}
+
+	return chip8, nil
 }
 
 type Chip8 struct {
 	logger   *log.Logger
+	romfile  atomic.Value // string, read by the file watcher
+	rom      []uint8
 	memory   *memory.Memory
 	display  *display.Display
 	keyboard *keyboard.Keyboard
 	sound    *sound.Sound
 	delay    *timer.Timer
 	cpu      *cpu.CPU
+
+	paused      bool
+	step        bool
+	turbo       float64 // speed multiplier while fast-forwarding
+	slow        float64 // speed multiplier in slow motion
+	fastForward bool
+	slowMotion  bool
+
+	reload atomic.Bool // set from the file watcher
 }
 
-func (c *Chip8) LoadROM(rom []uint8) {
+// LoadROM replaces the ROM and resets the machine. The ROM must fit between
+// 0x200 and the end of memory. As the ROM isn't read from a file, it can't be
+// reloaded.
+func (c *Chip8) LoadROM(rom []uint8) error {
+	return c.load("", rom)
+}
+
+// LoadFile reads the ROM from `romfile` and loads it.
+func (c *Chip8) LoadFile(romfile string) error {
+	rom, err := os.ReadFile(romfile)
+	if err != nil {
+		return err
+	}
+
+	return c.load(romfile, rom)
+}
+
+// load replaces the ROM, which was read from `romfile` if it isn't empty, and
+// resets the machine.
+func (c *Chip8) load(romfile string, rom []uint8) error {
+	if size := len(c.memory.RAM) - romStart; len(rom) > size {
+		return fmt.Errorf("rom is too large: %d bytes (max %d)", len(rom), size)
+	}
+
+	c.rom = rom
+	c.romfile.Store(romfile)
+	c.Reset()
+
+	return nil
+}
+
+// Reload reads the current ROM file from disk again and loads it.
+func (c *Chip8) Reload() error {
+	romfile := c.ROMFile()
+	if romfile == "" {
+		return fmt.Errorf("no rom file to reload")
+	}
+
+	return c.LoadFile(romfile)
+}
+
+// QueueReload reloads the ROM file on the next tick. Unlike the other methods,
+// it's safe to call from any goroutine.
+func (c *Chip8) QueueReload() {
+	c.reload.Store(true)
+}
+
+// ROMFile returns the path of the ROM file, if the ROM was loaded from disk.
+// It's safe to call from any goroutine.
+func (c *Chip8) ROMFile() string {
+	return c.romfile.Load().(string)
+}
+
+// ROM returns the ROM that is loaded.
+func (c *Chip8) ROM() []uint8 {
+	return c.rom
+}
+
+// Reset reinitializes memory (font and ROM), CPU, timers, display and
+// keyboard, as if the machine was just turned on.
+func (c *Chip8) Reset() {
+	c.memory.Clear()
+
+	// load already checked that these fit.
+	_ = c.memory.Load(0x000, digitSprites())
+	_ = c.memory.Load(romStart, c.rom)
+
+	c.cpu.Reset()
+	c.delay.Reset()
+	c.sound.Reset()
+	c.display.Reset()
+	c.keyboard.Reset()
+}
+
+// SetQuirks changes the platform specific behavior of the CPU.
+func (c *Chip8) SetQuirks(q quirks.Quirks) {
+	c.cpu.SetQuirks(q)
+}
+
+func (c *Chip8) Quirks() quirks.Quirks {
+	return c.cpu.Quirks()
+}
+
+// SetSpeed sets the number of instructions executed per second.
+func (c *Chip8) SetSpeed(ips uint) {
+	c.cpu.SetSpeed(ips)
+}
+
+func (c *Chip8) Speed() uint {
+	return c.cpu.Speed()
+}
+
+// Cycles returns the number of instructions executed so far.
+func (c *Chip8) Cycles() uint64 {
+	return c.cpu.Cycles()
+}
+
+// SetTurbo sets the speed multipliers for fast-forward and slow motion.
+func (c *Chip8) SetTurbo(turbo, slow float64) {
+	if turbo > 0 {
+		c.turbo = turbo
+	}
+
+	if slow > 0 {
+		c.slow = slow
+	}
+}
+
+// TogglePause pauses or resumes the machine.
+func (c *Chip8) TogglePause() {
+	c.paused = !c.paused
+}
+
+func (c *Chip8) Paused() bool {
+	return c.paused
+}
+
+// Step advances a paused machine by a single frame.
+func (c *Chip8) Step() {
+	if c.paused {
+		c.step = true
+	}
+}
+
+// SetFastForward runs the machine at the turbo speed while enabled.
+func (c *Chip8) SetFastForward(enabled bool) {
+	c.fastForward = enabled
+}
+
+func (c *Chip8) FastForward() bool {
+	return c.fastForward
+}
+
+// ToggleSlowMotion switches between slow motion and normal speed.
+func (c *Chip8) ToggleSlowMotion() {
+	c.slowMotion = !c.slowMotion
+}
+
+// Mode describes the current speed for an on-screen indicator. It's empty at
+// normal speed.
+func (c *Chip8) Mode() string {
+	switch {
+	case c.paused:
+		return "paused"
+	case c.fastForward:
+		return fmt.Sprintf("turbo x%g", c.turbo)
+	case c.slowMotion:
+		return fmt.Sprintf("slow x%g", c.slow)
+	default:
+		return ""
+	}
 }
 
 func (c *Chip8) Tick(dt time.Duration) {
+	if c.reload.Swap(false) {
+		if err := c.Reload(); err != nil {
+			c.logger.Errorf("failed to reload rom: %v", err)
+		} else {
+			c.logger.Infof("reloaded rom: %s", c.ROMFile())
+		}
+	}
+
+	switch {
+	case c.step:
+		c.step = false
+		dt = frame
+	case c.paused:
+		return
+	case c.fastForward:
+		dt = time.Duration(float64(dt) * c.turbo)
+	case c.slowMotion:
+		dt = time.Duration(float64(dt) * c.slow)
+	}
+
 	c.delay.Tick(dt)
 	c.sound.Tick(dt)
 	c.cpu.Tick(dt)
 }
 
+// Beeping returns whether the sound timer is active.
+func (c *Chip8) Beeping() bool {
+	return c.sound.Active()
+}
+
 func (c *Chip8) KeyDown(code uint8) {
 	c.keyboard.KeyDown(code)
 }
@@ -59,10 +258,26 @@ func (c *Chip8) KeyUp(code uint8) {
 	c.keyboard.KeyUp(code)
 }
 
+// IsKeyPressed returns whether the ROM sees the key as pressed.
+func (c *Chip8) IsKeyPressed(code uint8) bool {
+	return c.keyboard.IsKeyPressed(code)
+}
+
 func (c *Chip8) Framebuffer() [][]uint8 {
 	return c.display.Framebuffer
 }
 
+// Screen returns the brightness (0-255) of each pixel as it should be shown,
+// after flicker reduction.
+func (c *Chip8) Screen() [][]uint8 {
+	return c.display.Output()
+}
+
+// SetFilter changes the flicker reduction of the display.
+func (c *Chip8) SetFilter(f display.Filter) {
+	c.display.SetFilter(f)
+}
+
 func digitSprites() []uint8 {
 	return []uint8{
 		0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
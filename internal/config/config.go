@@ -0,0 +1,84 @@
+package config
+
+import (
+	"time"
+
+	"github.com/corani/chip-8/internal/keymap"
+	"github.com/corani/chip-8/internal/palette"
+)
+
+func New() *Config {
+	return &Config{
+		Title:        "chip-8",
+		ROMDir:       ".",
+		KeyTap:       30 * time.Millisecond,
+		KeyDelay:     500 * time.Millisecond,
+		KeyRepeat:    100 * time.Millisecond,
+		CaptureScale: 8,
+		Sound:        440,
+		Keymap:       keymap.Default(),
+	}
+}
+
+// Config holds the settings that the user interfaces need to present a ROM.
+type Config struct {
+	// Title is shown in the window title.
+	Title string
+	// Keys maps semantic keys (`up`, `down`, `left`, `right`, `a`, `b`) to
+	// CHIP-8 keys. They're already bound in `Keymap`, and are used for the
+	// gamepads.
+	Keys map[string]uint8
+	// Keymap maps the keys of the keyboard to CHIP-8 keys.
+	Keymap keymap.Keymap
+	// Palette holds the colors of the screen. If it's nil, the user
+	// interface uses its own default.
+	Palette *palette.Palette
+	// Renderer is the name of the TUI renderer (`block`, `half`, `braille`,
+	// or `graphics`, `kitty`, `sixel` for images).
+	Renderer string
+	// ROMDir is browsed for ROMs if none was given.
+	ROMDir string
+	// KeyTap is how long the TUI holds a key after it was first pressed, if
+	// the terminal doesn't report key releases.
+	KeyTap time.Duration
+	// KeyDelay is how long after a press another press of the same key is
+	// taken as a repeat. It should cover the delay before the terminal starts
+	// repeating the key.
+	KeyDelay time.Duration
+	// KeyRepeat is how long the TUI holds a key after a repeat, which should
+	// cover the interval between repeats.
+	KeyRepeat time.Duration
+	// Bell rings the terminal bell when the sound starts, as the TUI can't
+	// play sound.
+	Bell bool
+	// Keypad shows a keypad beside the screen, which can be clicked.
+	Keypad bool
+	// Cast is the path of an asciinema recording of the TUI session.
+	Cast string
+	// CaptureScale is the scale of screenshots and GIFs taken in the GUI.
+	CaptureScale int
+	// CRT configures the post-processing of the GUI.
+	CRT CRT
+	// CapturePalette overrides `Palette` for screenshots and GIFs.
+	CapturePalette *palette.Palette
+	// Scale is the initial scale of the GUI window. If it's zero, the window
+	// has a default size.
+	Scale int
+	// Sound is the pitch of the beep in Hz. Only the GUI plays sound.
+	Sound int
+	// ForROM applies the settings of a ROM that was picked or dropped, once
+	// it's loaded into the machine, and returns the config for it. `name` is
+	// the file name of the ROM.
+	ForROM func(name string) *Config
+	// Pads maps the gamepad buttons of each player (`up`, `a`, `start`, ...)
+	// to CHIP-8 keys, on top of the defaults and `Keys`.
+	Pads [2]map[string]uint8
+}
+
+// CRT configures the post-processing of the GUI, which gives it a retro look.
+type CRT struct {
+	Scanlines bool // darken the lower half of every row
+	Grid      bool // leave gaps between the pixels
+	Bloom     bool // let lit pixels glow
+	Integer   bool // only scale by whole numbers, instead of filling the window
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/corani/chip-8/internal/romdb"
+)
+
+// File holds the settings of a config file: the global ones, and the ones for
+// each ROM that has a section, which is named after the file name or the
+// SHA-1 hash of the ROM. Settings are named after the command line flags:
+//
+//	# all ROMs
+//	ui = gui
+//	theme = amber
+//
+//	[pong.ch8]
+//	speed = 1000
+//	keymap = azerty
+//
+//	[0df2789f661358d8f7370e6cf93490c5bcd44b01]
+//	platform = superchip
+//
+// Empty lines and lines starting with `#` are ignored.
+type File struct {
+	Global   Settings
+	sections []string // names of the ROM sections, in order
+	roms     map[string]Settings
+}
+
+// Settings maps the names of settings to their values.
+type Settings map[string]string
+
+// NewFile returns an empty config file.
+func NewFile() *File {
+	return &File{
+		Global: make(Settings),
+		roms:   make(map[string]Settings),
+	}
+}
+
+// DefaultPath returns the path of the config file in the user's config
+// directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "chip-8", "chip8.conf"), nil
+}
+
+// LoadFile reads the config file at `path`.
+func LoadFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, err := ReadFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return file, nil
+}
+
+// ReadFile parses a config file.
+func ReadFile(r io.Reader) (*File, error) {
+	file := NewFile()
+	section := file.Global
+
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = file.Section(strings.TrimSpace(line[1 : len(line)-1]))
+
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("line %d: expected name = value", n)
+		}
+
+		section[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return file, scanner.Err()
+}
+
+// Section returns the settings of the ROM section `name`, which is added if
+// it doesn't exist yet.
+func (f *File) Section(name string) Settings {
+	name = strings.ToLower(name)
+
+	if _, ok := f.roms[name]; !ok {
+		f.sections = append(f.sections, name)
+		f.roms[name] = make(Settings)
+	}
+
+	return f.roms[name]
+}
+
+// SectionName returns the name of the section for a ROM file.
+func SectionName(romfile string) string {
+	return strings.ToLower(filepath.Base(romfile))
+}
+
+// Settings returns the settings for the ROM: the global ones, overridden by
+// the ones for its file name, overridden by the ones for its hash.
+func (f *File) Settings(romfile string, rom []uint8) Settings {
+	settings := make(Settings)
+
+	for _, section := range f.sectionsFor(romfile, rom) {
+		for name, value := range section {
+			settings[name] = value
+		}
+	}
+
+	return settings
+}
+
+// Values returns the values of the setting `name` for the ROM, in the same
+// order of precedence as Settings, for settings that add up instead of
+// overriding each other.
+func (f *File) Values(name, romfile string, rom []uint8) []string {
+	var values []string
+
+	for _, section := range f.sectionsFor(romfile, rom) {
+		if value, ok := section[name]; ok {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// sectionsFor returns the sections that apply to the ROM, in increasing order
+// of precedence.
+func (f *File) sectionsFor(romfile string, rom []uint8) []Settings {
+	sections := []Settings{f.Global}
+
+	if romfile != "" {
+		sections = append(sections, f.roms[SectionName(romfile)])
+	}
+
+	if rom != nil {
+		sections = append(sections, f.roms[romdb.Hash(rom)])
+	}
+
+	return sections
+}
+
+// Save writes the config file to `path`, creating its directory if needed.
+// Comments aren't preserved.
+func (f *File) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Write(out); err != nil {
+		out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
+
+// Write writes the config file, with the settings of each section sorted by
+// name.
+func (f *File) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	writeSettings(bw, f.Global)
+
+	for _, name := range f.sections {
+		if len(f.roms[name]) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "\n[%s]\n", name)
+		writeSettings(bw, f.roms[name])
+	}
+
+	return bw.Flush()
+}
+
+func writeSettings(w io.Writer, settings Settings) {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "%s = %s\n", name, settings[name])
+	}
+}
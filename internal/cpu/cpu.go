@@ -9,9 +9,13 @@ import (
 	"github.com/corani/chip-8/internal/display"
 	"github.com/corani/chip-8/internal/keyboard"
 	"github.com/corani/chip-8/internal/memory"
+	"github.com/corani/chip-8/internal/quirks"
 	"github.com/corani/chip-8/internal/timer"
 )
 
+// vblankPeriod is the period of the vertical blank interrupt.
+const vblankPeriod = time.Second / 60
+
 func New(
 	l *log.Logger, m *memory.Memory, d *display.Display, k *keyboard.Keyboard,
 	dt, st *timer.Timer,
@@ -25,11 +29,18 @@ func New(
 		sound:    st,
 		fps:      500,
 		dt:       0,
-		reg:      [16]uint8{},
-		stack:    [16]uint16{},
-		i:        0,
-		pc:       0x200,
-		sp:       0,
+		// these match the behavior of this interpreter before quirks were
+		// configurable.
+		quirks: quirks.Quirks{
+			Shift:                 true,
+			MemoryLeaveIUnchanged: true,
+			Wrap:                  true,
+		},
+		reg:   [16]uint8{},
+		stack: [16]uint16{},
+		i:     0,
+		pc:    0x200,
+		sp:    0,
 	}
 }
 
@@ -42,6 +53,10 @@ type CPU struct {
 	sound    *timer.Timer
 	fps      uint
 	dt       time.Duration
+	quirks   quirks.Quirks
+	frame    time.Duration // time since the last vertical blank
+	vblank   bool          // waiting for the vertical blank
+	cycles   uint64        // instructions executed since the start
 
 	reg   [16]uint8  // general purpose registers
 	stack [16]uint16 // stack
@@ -50,12 +65,66 @@ type CPU struct {
 	sp    uint8      // stack pointer
 }
 
+// SetQuirks changes the platform specific behavior of the CPU.
+func (cpu *CPU) SetQuirks(q quirks.Quirks) {
+	cpu.quirks = q
+}
+
+func (cpu *CPU) Quirks() quirks.Quirks {
+	return cpu.quirks
+}
+
+// SetSpeed sets the number of instructions executed per second.
+func (cpu *CPU) SetSpeed(fps uint) {
+	if fps == 0 {
+		return
+	}
+
+	cpu.fps = fps
+}
+
+func (cpu *CPU) Speed() uint {
+	return cpu.fps
+}
+
+// Cycles returns the number of instructions executed since the CPU was
+// created. It isn't cleared by `Reset`, so it can be used to measure speed.
+func (cpu *CPU) Cycles() uint64 {
+	return cpu.cycles
+}
+
+// Reset clears the registers and stack, and restarts execution at 0x200.
+func (cpu *CPU) Reset() {
+	cpu.dt = 0
+	cpu.frame = 0
+	cpu.vblank = false
+	cpu.reg = [16]uint8{}
+	cpu.stack = [16]uint16{}
+	cpu.i = 0
+	cpu.pc = 0x200
+	cpu.sp = 0
+}
+
 func (cpu *CPU) Tick(dt time.Duration) {
 	cpu.dt += dt
 
 	for cpu.dt >= time.Second/time.Duration(cpu.fps) {
 		cpu.dt -= time.Second / time.Duration(cpu.fps)
+
+		cpu.frame += time.Second / time.Duration(cpu.fps)
+		for cpu.frame >= vblankPeriod {
+			cpu.frame -= vblankPeriod
+			cpu.vblank = false
+			cpu.display.VBlank()
+		}
+
+		// with the vblank quirk, drawing halts the CPU until the next frame.
+		if cpu.vblank {
+			continue
+		}
+
 		cpu.tick()
+		cpu.cycles++
 	}
 }
 
@@ -151,16 +220,28 @@ func (cpu *CPU) tick() {
 			dis += fmt.Sprintf("OR   V%x, V%x", x, y)
 
 			cpu.reg[x] |= cpu.reg[y]
+
+			if cpu.quirks.Logic {
+				cpu.reg[0xF] = 0
+			}
 		case 0x2:
 			// 8xy2: AND Vx, Vy
 			dis += fmt.Sprintf("AND  V%x, V%x", x, y)
 
 			cpu.reg[x] &= cpu.reg[y]
+
+			if cpu.quirks.Logic {
+				cpu.reg[0xF] = 0
+			}
 		case 0x3:
 			// 8xy3: XOR Vx, Vy
 			dis += fmt.Sprintf("XOR  V%x, V%x", x, y)
 
 			cpu.reg[x] ^= cpu.reg[y]
+
+			if cpu.quirks.Logic {
+				cpu.reg[0xF] = 0
+			}
 		case 0x4:
 			// 8xy4: ADD Vx, Vy
 			dis += fmt.Sprintf("ADD  V%x, V%x", x, y)
@@ -190,6 +271,11 @@ func (cpu *CPU) tick() {
 			// 8xy6: SHR Vx {, Vy}
 			dis += fmt.Sprintf("SHR  V%x {, V%x}", x, y)
 
+			// without the shift quirk, Vy is shifted into Vx.
+			if !cpu.quirks.Shift {
+				cpu.reg[x] = cpu.reg[y]
+			}
+
 			// set carry flag
 			if cpu.reg[x]&0x1 == 1 {
 				cpu.reg[0xF] = 1
@@ -216,6 +302,11 @@ func (cpu *CPU) tick() {
 			// 8xyE: SHL Vx {, Vy}
 			dis += fmt.Sprintf("SHL  V%x {, V%x}", x, y)
 
+			// without the shift quirk, Vy is shifted into Vx.
+			if !cpu.quirks.Shift {
+				cpu.reg[x] = cpu.reg[y]
+			}
+
 			// set carry flag
 			if cpu.reg[x]&0x80 == 0x80 {
 				cpu.reg[0xF] = 1
@@ -245,7 +336,12 @@ func (cpu *CPU) tick() {
 		// Bnnn: JP V0, addr
 		dis += fmt.Sprintf("JP   V0, %04x", addr)
 
-		cpu.pc = uint16(cpu.reg[0]) + uint16(addr)
+		// with the jump quirk, this is Bxnn: JP Vx, addr
+		if cpu.quirks.Jump {
+			cpu.pc = uint16(cpu.reg[x]) + uint16(addr)
+		} else {
+			cpu.pc = uint16(cpu.reg[0]) + uint16(addr)
+		}
 	case 0xC:
 		// Cxkk: RND Vx, byte
 		// The interpreter generates a random number from 0 to 255, which is then
@@ -260,11 +356,15 @@ func (cpu *CPU) tick() {
 		// set VF = collision.
 		dis += fmt.Sprintf("DRW  V%x, V%x, %x", x, y, n)
 
-		if cpu.display.Blit(uint16(cpu.reg[x]), uint16(cpu.reg[y]), cpu.memory.ReadRange(cpu.i, n)) {
+		sprite := cpu.memory.ReadRange(cpu.i, n)
+
+		if cpu.display.Blit(uint16(cpu.reg[x]), uint16(cpu.reg[y]), sprite, cpu.quirks.Wrap) {
 			cpu.reg[0xF] = 1
 		} else {
 			cpu.reg[0xF] = 0
 		}
+
+		cpu.vblank = cpu.quirks.VBlank
 	case 0xE:
 		switch kk {
 		case 0x9E:
@@ -340,6 +440,8 @@ func (cpu *CPU) tick() {
 			for i := uint16(0); i <= uint16(x); i++ {
 				cpu.memory.WriteByte(cpu.i+i, cpu.reg[i])
 			}
+
+			cpu.incrementI(x)
 		case 0x65:
 			// Fx65: LD Vx, [I]
 			// Read memory starting at I into register v0..Vx
@@ -348,6 +450,8 @@ func (cpu *CPU) tick() {
 			for i := uint16(0); i <= uint16(x); i++ {
 				cpu.reg[i] = cpu.memory.ReadByte(cpu.i + i)
 			}
+
+			cpu.incrementI(x)
 		}
 	}
 
@@ -363,6 +467,18 @@ func (cpu *CPU) tick() {
 	*/
 
 	if cpu.logger != nil {
-		cpu.logger.Infof(dis)
+		cpu.logger.Info(dis)
+	}
+}
+
+// incrementI updates I after Fx55/Fx65, depending on the memory quirks.
+func (cpu *CPU) incrementI(x uint16) {
+	switch {
+	case cpu.quirks.MemoryLeaveIUnchanged:
+		// leave I unchanged
+	case cpu.quirks.MemoryIncrementByX:
+		cpu.i += x
+	default:
+		cpu.i += x + 1
 	}
 }
@@ -6,24 +6,115 @@ func New(logger *log.Logger) *Display {
 	width := 64
 	height := 32
 
-	fb := make([][]uint8, width)
-	for x := 0; x < width; x++ {
-		fb[x] = make([]uint8, height)
-	}
-
 	return &Display{
 		logger:      logger,
 		width:       64,
 		height:      32,
-		Framebuffer: fb,
+		Framebuffer: newBuffer(width, height),
+		output:      newBuffer(width, height),
+		previous:    newBuffer(width, height),
 	}
 }
 
+func newBuffer(width, height int) [][]uint8 {
+	buf := make([][]uint8, width)
+	for x := 0; x < width; x++ {
+		buf[x] = make([]uint8, height)
+	}
+
+	return buf
+}
+
+// Filter reduces the flicker caused by sprites being erased and redrawn. The
+// filters are applied once per 60Hz frame.
+type Filter struct {
+	// VBlank only presents the framebuffer once per frame, instead of showing
+	// whatever was drawn so far.
+	VBlank bool
+	// Blend shows a pixel if it was set in either of the last two frames.
+	Blend bool
+	// Decay fades out pixels over this many frames, like the phosphor of a
+	// CRT.
+	Decay int
+}
+
+// Enabled returns whether any filter is enabled.
+func (f Filter) Enabled() bool {
+	return f.VBlank || f.Blend || f.Decay > 0
+}
+
 type Display struct {
 	logger      *log.Logger
 	width       int
 	height      int
 	Framebuffer [][]uint8
+	filter      Filter
+	output      [][]uint8 // brightness of the pixels as presented
+	previous    [][]uint8 // framebuffer at the previous frame
+}
+
+// SetFilter changes the flicker reduction.
+func (d *Display) SetFilter(f Filter) {
+	d.filter = f
+}
+
+// Output returns the brightness (0-255) of each pixel as it should be shown.
+// Without a filter, this follows the framebuffer as it's drawn.
+func (d *Display) Output() [][]uint8 {
+	if !d.filter.Enabled() {
+		for x := 0; x < d.width; x++ {
+			for y := 0; y < d.height; y++ {
+				d.output[x][y] = d.Framebuffer[x][y] * 255
+			}
+		}
+	}
+
+	return d.output
+}
+
+// VBlank presents the framebuffer at the end of a 60Hz frame.
+func (d *Display) VBlank() {
+	if !d.filter.Enabled() {
+		return
+	}
+
+	// rounded up, so pixels are gone after `Decay` frames.
+	fade := 255
+	if d.filter.Decay > 0 {
+		fade = (255 + d.filter.Decay - 1) / d.filter.Decay
+	}
+
+	for x := 0; x < d.width; x++ {
+		for y := 0; y < d.height; y++ {
+			on := d.Framebuffer[x][y] != 0
+			if d.filter.Blend && d.previous[x][y] != 0 {
+				on = true
+			}
+
+			switch {
+			case on:
+				d.output[x][y] = 255
+			case int(d.output[x][y]) > fade:
+				d.output[x][y] -= uint8(fade)
+			default:
+				d.output[x][y] = 0
+			}
+
+			d.previous[x][y] = d.Framebuffer[x][y]
+		}
+	}
+}
+
+// Reset clears the framebuffer, as well as the frames kept by the filters.
+func (d *Display) Reset() {
+	d.Clear()
+
+	for x := 0; x < d.width; x++ {
+		for y := 0; y < d.height; y++ {
+			d.output[x][y] = 0
+			d.previous[x][y] = 0
+		}
+	}
 }
 
 func (d *Display) Clear() {
@@ -34,11 +125,17 @@ func (d *Display) Clear() {
 	}
 }
 
-func (d *Display) Blit(sx, sy uint16, sprite []uint8) bool {
+// Blit draws the sprite at (sx, sy) and reports whether any pixels were
+// erased. The starting position always wraps around the screen, the sprite
+// itself wraps if `wrap` is set and is clipped otherwise.
+func (d *Display) Blit(sx, sy uint16, sprite []uint8, wrap bool) bool {
 	// all sprites are 8 pixels (bits) wide
 	const width = uint16(8)
 	height := uint16(len(sprite))
 
+	sx %= uint16(d.width)
+	sy %= uint16(d.height)
+
 	res := false
 
 	for y := uint16(0); y < height; y++ {
@@ -46,7 +143,10 @@ func (d *Display) Blit(sx, sy uint16, sprite []uint8) bool {
 			// get the correct bit
 			val := (sprite[y] >> (width - x - 1)) & 0x01
 
-			// sprites need to wrap!
+			if !wrap && (sx+x >= uint16(d.width) || sy+y >= uint16(d.height)) {
+				continue
+			}
+
 			px := (sx + x) % uint16(d.width)
 			py := (sy + y) % uint16(d.height)
 
@@ -0,0 +1,78 @@
+package gui
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// sampleRate of the beep, in samples per second.
+const sampleRate = 44100
+
+// volume of the beep, between 0 and 1. A square wave is loud.
+const volume = 0.2
+
+// beep plays a square wave while the sound timer of the machine is active.
+type beep struct {
+	player *audio.Player
+	tone   *tone
+}
+
+func newBeep() (*beep, error) {
+	t := new(tone)
+
+	player, err := audio.NewContext(sampleRate).NewPlayer(t)
+	if err != nil {
+		return nil, err
+	}
+
+	player.SetVolume(volume)
+	// keep the delay between the sound timer and the beep short.
+	player.SetBufferSize(50 * time.Millisecond)
+
+	return &beep{player: player, tone: t}, nil
+}
+
+// setPitch changes the pitch of the beep, in Hz.
+func (b *beep) setPitch(hz int) {
+	b.tone.pitch.Store(int64(hz))
+}
+
+// update plays or pauses the beep, depending on whether the machine is
+// `beeping`.
+func (b *beep) update(beeping bool) {
+	switch {
+	case beeping && !b.player.IsPlaying():
+		b.player.Play()
+	case !beeping && b.player.IsPlaying():
+		b.player.Pause()
+	}
+}
+
+// tone is an endless square wave, as 16-bit little endian stereo samples.
+type tone struct {
+	pitch atomic.Int64 // in Hz, set from the game loop
+	phase int64        // in samples, only used by the audio goroutine
+}
+
+func (t *tone) Read(p []byte) (int, error) {
+	// a full period is at least two samples, one high and one low.
+	period := max(2, sampleRate/max(1, t.pitch.Load()))
+
+	n := len(p) / 4 * 4
+
+	for i := 0; i < n; i += 4 {
+		var v int16 = -0x7fff
+		if t.phase < period/2 {
+			v = 0x7fff
+		}
+
+		t.phase = (t.phase + 1) % period
+
+		p[i], p[i+1] = byte(v), byte(v>>8)
+		p[i+2], p[i+3] = byte(v), byte(v>>8)
+	}
+
+	return n, nil
+}
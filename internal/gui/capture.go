@@ -0,0 +1,163 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// minDelay is the shortest time a GIF frame is shown. Most viewers slow down
+// frames with shorter delays, so faster changes are merged.
+const minDelay = 20 * time.Millisecond
+
+// capture saves screenshots and animated GIFs of the framebuffer.
+type capture struct {
+	scale     int
+	palette   color.Palette
+	recording *recording // set while recording a GIF
+}
+
+// recording holds the frames of a GIF until it's saved. The frames are kept
+// at the original resolution and only scaled when saving.
+type recording struct {
+	path   string
+	frames [][][]uint8
+	delays []time.Duration // how long each frame was shown
+}
+
+// image converts the framebuffer to a paletted image, scaled up by the
+// capture scale.
+func (c *capture) image(fb [][]uint8) *image.Paletted {
+	width, height := len(fb), len(fb[0])
+	img := image.NewPaletted(image.Rect(0, 0, width*c.scale, height*c.scale), c.palette)
+
+	for y := range img.Rect.Dy() {
+		for x := range img.Rect.Dx() {
+			if fb[x/c.scale][y/c.scale] != 0 {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	return img
+}
+
+// filename returns a timestamped name next to the ROM, or in the working
+// directory if the ROM wasn't loaded from a file.
+func filename(romfile, ext string) string {
+	dir, name := ".", "chip8"
+
+	if romfile != "" {
+		dir = filepath.Dir(romfile)
+		name = strings.TrimSuffix(filepath.Base(romfile), filepath.Ext(romfile))
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", name, time.Now().Format("20060102-150405"), ext))
+}
+
+// screenshot saves the framebuffer as a PNG.
+func (c *capture) screenshot(romfile string, fb [][]uint8) (string, error) {
+	path := filename(romfile, ".png")
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, c.image(fb)); err != nil {
+		return "", err
+	}
+
+	return path, f.Close()
+}
+
+// start starts recording a GIF.
+func (c *capture) start(romfile string) {
+	c.recording = &recording{path: filename(romfile, ".gif")}
+}
+
+// stop stops recording and saves the GIF in the background, as encoding a
+// long recording takes a while. `done` is called once it was saved.
+func (c *capture) stop(done func(path string, err error)) {
+	r := c.recording
+	c.recording = nil
+
+	go func() {
+		done(r.path, c.save(r))
+	}()
+}
+
+// add records the framebuffer, `dt` after the previous frame. Frames that are
+// identical to the previous one only extend its delay.
+func (r *recording) add(fb [][]uint8, dt time.Duration) {
+	n := len(r.frames)
+
+	if n > 0 {
+		r.delays[n-1] += dt
+
+		if equal(r.frames[n-1], fb) {
+			return
+		}
+
+		if r.delays[n-1] < minDelay {
+			r.frames[n-1] = clone(fb)
+
+			return
+		}
+	}
+
+	r.frames = append(r.frames, clone(fb))
+	r.delays = append(r.delays, 0)
+}
+
+func (c *capture) save(r *recording) error {
+	anim := &gif.GIF{}
+
+	// the delays are in 100ths of a second. Rounding the total time instead of
+	// every single delay keeps the animation from drifting.
+	var total time.Duration
+
+	shown := 0
+
+	for i, fb := range r.frames {
+		total += max(r.delays[i], minDelay)
+		delay := int(total.Round(10*time.Millisecond)/(10*time.Millisecond)) - shown
+		shown += delay
+
+		anim.Image = append(anim.Image, c.image(fb))
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	f, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := gif.EncodeAll(f, anim); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func equal(a, b [][]uint8) bool {
+	return slices.EqualFunc(a, b, slices.Equal)
+}
+
+func clone(fb [][]uint8) [][]uint8 {
+	out := make([][]uint8, len(fb))
+	for x := range fb {
+		out[x] = slices.Clone(fb[x])
+	}
+
+	return out
+}
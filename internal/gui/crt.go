@@ -0,0 +1,238 @@
+package gui
+
+import (
+	"math"
+
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/palette"
+)
+
+// Strength of the CRT effects. The dimming is in 256ths.
+const (
+	gridDim     = 90  // brightness of the gaps between pixels
+	scanlineDim = 154 // brightness of the lower half of every row
+	glow        = 128 // brightness of the bloom
+)
+
+// bloomKernel is a small gaussian to blur the screen for the bloom.
+var bloomKernel = []float32{1.0 / 16, 4.0 / 16, 6.0 / 16, 4.0 / 16, 1.0 / 16}
+
+// crt upscales the framebuffer to the window on the CPU, adding scanlines, gaps
+// between pixels and bloom to give it a retro look.
+type crt struct {
+	config.CRT
+	width, height int
+	pixels        []uint8
+	cols, rows    []span
+	glow          [][]float32 // blurred brightness of the screen
+}
+
+// span maps an output column or row to the screen.
+type span struct {
+	src  int  // pixel of the screen, or -1 outside of it
+	edge bool // last column or row of a pixel, for the grid
+	low  bool // lower half of a pixel, for the scanlines
+
+	// the bloom interpolates between the centers of two pixels.
+	lo, hi int
+	frac   float32 // of `hi`
+	weight int     // of `hi`, in 256ths
+}
+
+// same returns whether both spans show the same part of a pixel.
+func (s span) same(o span) bool {
+	return s.src == o.src && s.edge == o.edge && s.low == o.low
+}
+
+func (c *crt) enabled() bool {
+	return c.Scanlines || c.Grid || c.Bloom || c.Integer
+}
+
+// resize prepares the spans for a window of `width` x `height`, showing a
+// screen of `srcWidth` x `srcHeight`.
+func (c *crt) resize(width, height, srcWidth, srcHeight int) {
+	if width == c.width && height == c.height {
+		return
+	}
+
+	c.width, c.height = width, height
+	c.pixels = make([]uint8, width*height*4)
+
+	scale := min(float64(width)/float64(srcWidth), float64(height)/float64(srcHeight))
+	if c.Integer {
+		scale = max(1, math.Floor(scale))
+	}
+
+	c.cols = spans(width, srcWidth, scale)
+	c.rows = spans(height, srcHeight, scale)
+
+	c.glow = make([][]float32, srcWidth)
+	for x := range c.glow {
+		c.glow[x] = make([]float32, srcHeight)
+	}
+}
+
+// spans maps `size` output pixels to `srcSize` screen pixels, scaled by `scale`
+// and centered.
+func spans(size, srcSize int, scale float64) []span {
+	out := make([]span, size)
+	offset := (float64(size) - float64(srcSize)*scale) / 2
+
+	for i := range out {
+		pos := (float64(i) - offset) / scale
+		src := int(math.Floor(pos))
+
+		if pos < 0 || src >= srcSize {
+			out[i] = span{src: -1}
+
+			continue
+		}
+
+		next := int(math.Floor((float64(i+1) - offset) / scale))
+		center := pos - 0.5
+		lo := int(math.Floor(center))
+
+		out[i] = span{
+			src: src,
+			// the effects need a few output pixels per screen pixel.
+			edge:   scale >= 3 && next != src,
+			low:    scale >= 2 && pos-float64(src) >= 0.5,
+			lo:     min(max(lo, 0), srcSize-1),
+			hi:     min(max(lo+1, 0), srcSize-1),
+			frac:   float32(center - math.Floor(center)),
+			weight: int(256 * (center - math.Floor(center))),
+		}
+	}
+
+	return out
+}
+
+// process upscales the screen, where `pixels` are its colors and `screen` the
+// brightness of each pixel, into `c.pixels`.
+func (c *crt) process(pixels []uint8, screen [][]uint8, pal palette.Palette) {
+	if c.Bloom {
+		c.blur(screen)
+	}
+
+	bg, fg := pal.Background(), pal.Foreground()
+	bgc := [3]int{int(bg.R), int(bg.G), int(bg.B)}
+	fgc := [3]int{int(fg.R), int(fg.G), int(fg.B)}
+	srcWidth := len(screen)
+	stride := c.width * 4
+
+	// the glow of the current row, at the resolution of the screen, in 256ths.
+	light := make([]int, srcWidth)
+	base := make([][3]int, srcWidth*2)
+
+	for y, row := range c.rows {
+		out := c.pixels[y*stride : (y+1)*stride]
+
+		// without bloom, rows showing the same part of the screen are equal.
+		if !c.Bloom && y > 0 && row.same(c.rows[y-1]) {
+			copy(out, c.pixels[(y-1)*stride:y*stride])
+
+			continue
+		}
+
+		if row.src < 0 {
+			for x := 0; x < len(out); x += 4 {
+				out[x], out[x+1], out[x+2], out[x+3] = bg.R, bg.G, bg.B, bg.A
+			}
+
+			continue
+		}
+
+		rowDim := 256
+		if c.Scanlines && row.low {
+			rowDim = scanlineDim
+		}
+
+		if c.Bloom {
+			for x := range light {
+				g := c.glow[x][row.lo]*(1-row.frac) + c.glow[x][row.hi]*row.frac
+				light[x] = int(g * glow)
+			}
+		}
+
+		// the dimmed colors of the pixels in this row, without and with a gap
+		// of the grid.
+		src := pixels[row.src*srcWidth*4 : (row.src+1)*srcWidth*4]
+
+		for x := range srcWidth {
+			for gap := range 2 {
+				dim := rowDim
+				if c.Grid && (row.edge || gap == 1) {
+					dim = dim * gridDim / 256
+				}
+
+				for i := range 3 {
+					// dim towards the background, so the effects work for
+					// light themes too.
+					base[x*2+gap][i] = bgc[i] + (int(src[x*4+i])-bgc[i])*dim/256
+				}
+			}
+		}
+
+		for x, col := range c.cols {
+			o := out[x*4 : x*4+4]
+
+			if col.src < 0 {
+				o[0], o[1], o[2], o[3] = bg.R, bg.G, bg.B, bg.A
+
+				continue
+			}
+
+			b := &base[col.src*2]
+			if col.edge {
+				b = &base[col.src*2+1]
+			}
+
+			if !c.Bloom {
+				o[0], o[1], o[2], o[3] = uint8(b[0]), uint8(b[1]), uint8(b[2]), 255
+
+				continue
+			}
+
+			// all of these are positive, so shifting is the same as dividing by
+			// 256, but faster.
+			g := (light[col.lo]*(256-col.weight) + light[col.hi]*col.weight) >> 8
+
+			o[0] = uint8(min(255, b[0]+(g*fgc[0])>>8))
+			o[1] = uint8(min(255, b[1]+(g*fgc[1])>>8))
+			o[2] = uint8(min(255, b[2]+(g*fgc[2])>>8))
+			o[3] = 255
+		}
+	}
+}
+
+// blur computes the glow of the screen, with a separable gaussian.
+func (c *crt) blur(screen [][]uint8) {
+	width, height := len(screen), len(screen[0])
+	r := len(bloomKernel) / 2
+
+	horizontal := make([][]float32, width)
+
+	for x := range horizontal {
+		horizontal[x] = make([]float32, height)
+
+		for y := range horizontal[x] {
+			for k, w := range bloomKernel {
+				if sx := x + k - r; sx >= 0 && sx < width {
+					horizontal[x][y] += w * float32(screen[sx][y]) / 255
+				}
+			}
+		}
+	}
+
+	for x := range c.glow {
+		for y := range c.glow[x] {
+			c.glow[x][y] = 0
+
+			for k, w := range bloomKernel {
+				if sy := y + k - r; sy >= 0 && sy < height {
+					c.glow[x][y] += w * horizontal[x][sy]
+				}
+			}
+		}
+	}
+}
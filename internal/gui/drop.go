@@ -0,0 +1,67 @@
+package gui
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/corani/chip-8/internal/roms"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// updateDrop loads the first ROM that was dropped onto the window.
+func (app *App) updateDrop() {
+	files := ebiten.DroppedFiles()
+	if files == nil {
+		return
+	}
+
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		app.logger.Errorf("failed to read dropped files: %v", err)
+
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() || !slices.Contains(roms.Extensions, strings.ToLower(path.Ext(name))) {
+			app.logger.Warnf("ignoring dropped file: %s (supported: %s)",
+				name, strings.Join(roms.Extensions, ", "))
+
+			continue
+		}
+
+		if err := app.loadDropped(files, name); err != nil {
+			app.logger.Errorf("failed to load dropped rom: %v", err)
+		}
+
+		return
+	}
+}
+
+// loadDropped loads the ROM `name` from the dropped files.
+func (app *App) loadDropped(files fs.FS, name string) error {
+	f, err := files.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// on desktops the dropped files are real files, so load them by path to
+	// keep reloading and naming captures after the ROM.
+	if file, ok := f.(*os.File); ok {
+		return app.loadROM(name, file.Name(), nil)
+	}
+
+	rom, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	return app.loadROM(name, "", rom)
+}
@@ -0,0 +1,193 @@
+package gui
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// players is the number of gamepads that can be used at the same time.
+const players = 2
+
+// stickDeadZone is how far the left stick has to be pushed to count as the
+// d-pad.
+const stickDeadZone = 0.5
+
+// padButtons are the names of the buttons in gamepad mappings, for gamepads
+// with the standard layout. The left stick is mapped to the d-pad.
+var padButtons = map[string]ebiten.StandardGamepadButton{
+	"up":     ebiten.StandardGamepadButtonLeftTop,
+	"down":   ebiten.StandardGamepadButtonLeftBottom,
+	"left":   ebiten.StandardGamepadButtonLeftLeft,
+	"right":  ebiten.StandardGamepadButtonLeftRight,
+	"a":      ebiten.StandardGamepadButtonRightBottom,
+	"b":      ebiten.StandardGamepadButtonRightRight,
+	"x":      ebiten.StandardGamepadButtonRightLeft,
+	"y":      ebiten.StandardGamepadButtonRightTop,
+	"l":      ebiten.StandardGamepadButtonFrontTopLeft,
+	"r":      ebiten.StandardGamepadButtonFrontTopRight,
+	"select": ebiten.StandardGamepadButtonCenterLeft,
+	"start":  ebiten.StandardGamepadButtonCenterRight,
+}
+
+// defaultPad maps the d-pad to 5/7/8/9 and the face buttons to 6 and 4, as
+// many ROMs use these for movement and action.
+var defaultPad = map[string]uint8{
+	"up": 0x5, "down": 0x8, "left": 0x7, "right": 0x9, "a": 0x6, "b": 0x4,
+}
+
+// player2Keys are the semantic keys of the second player in the
+// chip-8-database.
+var player2Keys = map[string]string{
+	"player2Up": "up", "player2Down": "down", "player2Left": "left",
+	"player2Right": "right", "player2A": "a", "player2B": "b",
+}
+
+// gamepad is a player slot, which a connected gamepad is assigned to.
+type gamepad struct {
+	id        ebiten.GamepadID
+	connected bool
+	mapping   map[string]uint8 // button name to CHIP-8 key
+	pressed   map[uint8]bool
+}
+
+// padMappings builds the mapping of each player: the defaults for the first
+// player, then the semantic keys of the ROM, then the configured mappings.
+func padMappings(logger *log.Logger, keys map[string]uint8, pads [players]map[string]uint8) [players]map[string]uint8 {
+	var mappings [players]map[string]uint8
+
+	for i := range mappings {
+		mappings[i] = make(map[string]uint8)
+	}
+
+	for button, code := range defaultPad {
+		mappings[0][button] = code
+	}
+
+	for name, code := range keys {
+		if _, ok := padButtons[name]; ok {
+			mappings[0][name] = code
+		} else if button, ok := player2Keys[name]; ok {
+			mappings[1][button] = code
+		}
+	}
+
+	for i, pad := range pads {
+		for button, code := range pad {
+			if _, ok := padButtons[button]; !ok {
+				logger.Warnf("unknown gamepad button %q of player %d", button, i+1)
+
+				continue
+			}
+
+			mappings[i][button] = code
+		}
+	}
+
+	return mappings
+}
+
+// updateGamepads assigns newly connected gamepads to free player slots,
+// releases the keys of disconnected ones, and presses the mapped keys.
+func (app *App) updateGamepads() {
+	connected := make(map[ebiten.GamepadID]bool)
+
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		connected[id] = true
+	}
+
+	for i, pad := range app.pads {
+		if pad.connected && !connected[pad.id] {
+			app.logger.Infof("gamepad of player %d disconnected", i+1)
+
+			pad.connected = false
+			app.pressPad(pad, nil)
+		}
+	}
+
+	for id := range connected {
+		app.assignGamepad(id)
+	}
+
+	for _, pad := range app.pads {
+		if pad.connected {
+			app.pressPad(pad, pad.held())
+		}
+	}
+}
+
+// assignGamepad gives the gamepad the first free player slot, unless it
+// already has one.
+func (app *App) assignGamepad(id ebiten.GamepadID) {
+	var free *gamepad
+
+	for _, pad := range app.pads {
+		if pad.connected && pad.id == id {
+			return
+		}
+
+		if !pad.connected && free == nil {
+			free = pad
+		}
+	}
+
+	if free == nil {
+		return
+	}
+
+	// without the standard layout, there's no telling which button is which.
+	if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+		if !app.unknownPads[id] {
+			app.logger.Warnf("gamepad %q has an unknown layout", ebiten.GamepadName(id))
+			app.unknownPads[id] = true
+		}
+
+		return
+	}
+
+	free.id, free.connected = id, true
+
+	for i, pad := range app.pads {
+		if pad == free {
+			app.logger.Infof("gamepad %q connected as player %d", ebiten.GamepadName(id), i+1)
+		}
+	}
+}
+
+// held returns the keys mapped to the buttons that are pressed.
+func (pad *gamepad) held() map[uint8]bool {
+	held := make(map[uint8]bool)
+
+	x := ebiten.StandardGamepadAxisValue(pad.id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	y := ebiten.StandardGamepadAxisValue(pad.id, ebiten.StandardGamepadAxisLeftStickVertical)
+
+	stick := map[string]bool{
+		"up": y < -stickDeadZone, "down": y > stickDeadZone,
+		"left": x < -stickDeadZone, "right": x > stickDeadZone,
+	}
+
+	for name, code := range pad.mapping {
+		if ebiten.IsStandardGamepadButtonPressed(pad.id, padButtons[name]) || stick[name] {
+			held[code] = true
+		}
+	}
+
+	return held
+}
+
+// pressPad presses the keys in `held` that weren't pressed before, and
+// releases the ones that are no longer held.
+func (app *App) pressPad(pad *gamepad, held map[uint8]bool) {
+	for code := range held {
+		if !pad.pressed[code] {
+			app.chip8.KeyDown(code)
+		}
+	}
+
+	for code := range pad.pressed {
+		if !held[code] {
+			app.chip8.KeyUp(code)
+		}
+	}
+
+	pad.pressed = held
+}
@@ -1,36 +1,89 @@
 package gui
 
 import (
+	"image"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/corani/chip-8/internal/chip8"
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/keymap"
+	"github.com/corani/chip-8/internal/palette"
+	"github.com/corani/chip-8/internal/roms"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
-func New(log *log.Logger, chip8 *chip8.Chip8) *App {
-	keyMap := map[ebiten.Key]uint8{
-		ebiten.Key1: 0x1, ebiten.Key2: 0x2, ebiten.Key3: 0x3, ebiten.Key4: 0xC,
-		ebiten.KeyQ: 0x4, ebiten.KeyW: 0x5, ebiten.KeyE: 0x6, ebiten.KeyR: 0xD,
-		ebiten.KeyA: 0x7, ebiten.KeyS: 0x8, ebiten.KeyD: 0x9, ebiten.KeyF: 0xE,
-		ebiten.KeyZ: 0xA, ebiten.KeyX: 0x0, ebiten.KeyC: 0xB, ebiten.KeyV: 0xF,
-	}
-	return &App{
+func New(log *log.Logger, chip8 *chip8.Chip8, cfg *config.Config) *App {
+	app := &App{
 		logger: log,
 		chip8:  chip8,
 		pixels: make([]uint8, 64*32*4),
 		time:   time.Now(),
-		keyMap: keyMap,
+		scale:  cfg.Scale,
+		forROM: cfg.ForROM,
+	}
+
+	if chip8.ROMFile() == "" {
+		picker, err := roms.NewPicker(cfg.ROMDir)
+		if err != nil {
+			log.Errorf("failed to list roms: %v", err)
+		} else {
+			app.picker = picker
+		}
 	}
+
+	app.crt = &crt{CRT: cfg.CRT}
+
+	app.unknownPads = make(map[ebiten.GamepadID]bool)
+
+	for i := range app.pads {
+		app.pads[i] = new(gamepad)
+	}
+
+	if cfg.Keypad {
+		app.keypad = newKeypad()
+	}
+
+	if b, err := newBeep(); err != nil {
+		log.Errorf("failed to initialize sound: %v", err)
+	} else {
+		app.beep = b
+	}
+
+	app.capture = &capture{scale: max(1, cfg.CaptureScale)}
+	app.useConfig(cfg)
+
+	return app
 }
 
 type App struct {
-	logger *log.Logger
-	chip8  *chip8.Chip8
-	pixels []uint8
-	time   time.Time
-	keyMap map[ebiten.Key]uint8
+	logger  *log.Logger
+	chip8   *chip8.Chip8
+	title   string
+	pixels  []uint8
+	time    time.Time
+	keyMap  keymap.Keymap
+	palette palette.Palette
+	picker  *roms.Picker
+	scale   int // of the window
+	forROM  func(name string) *config.Config
+
+	capture *capture
+	crt     *crt
+	keypad  *keypad
+	beep    *beep // nil without sound
+
+	pads        [players]*gamepad
+	unknownPads map[ebiten.GamepadID]bool // warned about their layout
+
+	// at the full resolution of the window, `area` is where the screen is
+	// drawn, using `image` or `crtImage` as buffer.
+	area     image.Rectangle
+	image    *ebiten.Image
+	crtImage *ebiten.Image
 }
 
 func (app *App) Update() error {
@@ -38,53 +91,210 @@ func (app *App) Update() error {
 	dt := now.Sub(app.time)
 	app.time = now
 
+	app.updateDrop()
+
+	if app.picker != nil {
+		return app.updatePicker()
+	}
+
 	for _, key := range inpututil.AppendJustPressedKeys(nil) {
-		if k, ok := app.keyMap[key]; ok {
+		if k, ok := app.keyMap.Key(keyName(key)); ok {
 			app.chip8.KeyDown(k)
+
+			continue
+		}
+
+		switch key {
+		case ebiten.KeyF2:
+			app.chip8.TogglePause()
+		case ebiten.KeyF3:
+			app.chip8.Step()
+		case ebiten.KeyF4:
+			app.chip8.ToggleSlowMotion()
+		case ebiten.KeyF5:
+			app.chip8.Reset()
+		case ebiten.KeyF6:
+			if err := app.chip8.Reload(); err != nil {
+				app.logger.Errorf("failed to reload rom: %v", err)
+			}
+		case ebiten.KeyF12:
+			app.screenshot()
+		case ebiten.KeyF9:
+			app.toggleRecording()
 		}
 	}
 
 	for _, key := range inpututil.AppendJustReleasedKeys(nil) {
-		if k, ok := app.keyMap[key]; ok {
+		if k, ok := app.keyMap.Key(keyName(key)); ok {
 			app.chip8.KeyUp(k)
 		}
 	}
 
+	if app.keypad != nil {
+		app.updateKeypad()
+	}
+
+	app.updateGamepads()
+
+	// fast-forward while the key is held.
+	app.chip8.SetFastForward(ebiten.IsKeyPressed(ebiten.KeyTab))
+
 	app.chip8.Tick(dt)
 
-	fb := app.chip8.Framebuffer()
+	if app.beep != nil {
+		// the sound timer doesn't count down while paused.
+		app.beep.update(app.chip8.Beeping() && !app.chip8.Paused())
+	}
+
+	if app.capture.recording != nil {
+		app.capture.recording.add(app.chip8.Framebuffer(), dt)
+	}
+
+	screen := app.chip8.Screen()
 
 	for y := 0; y < 32; y++ {
 		for x := 0; x < 64; x++ {
 			idx := (y*64 + x) * 4
-			if fb[x][y] == 0 {
-				app.pixels[idx] = 0
-				app.pixels[idx+1] = 0
-				app.pixels[idx+2] = 0
-				app.pixels[idx+3] = 255
-			} else {
-				app.pixels[idx] = 255
-				app.pixels[idx+1] = 255
-				app.pixels[idx+2] = 255
-				app.pixels[idx+3] = 255
-			}
+
+			c := app.palette.Pixel(screen[x][y])
+
+			app.pixels[idx] = c.R
+			app.pixels[idx+1] = c.G
+			app.pixels[idx+2] = c.B
+			app.pixels[idx+3] = c.A
 		}
 	}
 
+	if app.crt.enabled() && app.crt.pixels != nil {
+		app.crt.process(app.pixels, screen, app.palette)
+	}
+
 	return nil
 }
 
 func (app *App) Draw(screen *ebiten.Image) {
-	screen.WritePixels(app.pixels)
+	if app.picker != nil {
+		app.drawPicker(screen)
+
+		return
+	}
+
+	app.drawScreen(screen)
+
+	if app.keypad != nil {
+		app.drawKeypad(screen)
+	}
+
+	status := app.chip8.Mode()
+	if app.capture.recording != nil {
+		status = strings.TrimSpace("rec " + status)
+	}
+
+	// the status is drawn at the resolution of the window, so it stays legible.
+	if status != "" {
+		ebitenutil.DebugPrintAt(screen, status, app.area.Min.X+4, app.area.Min.Y+4)
+	}
+}
+
+// screenshot saves the current framebuffer as a PNG next to the ROM.
+func (app *App) screenshot() {
+	path, err := app.capture.screenshot(app.chip8.ROMFile(), app.chip8.Framebuffer())
+	if err != nil {
+		app.logger.Errorf("failed to save screenshot: %v", err)
+
+		return
+	}
+
+	app.logger.Infof("saved screenshot: %s", path)
+}
+
+// toggleRecording starts or stops recording an animated GIF.
+func (app *App) toggleRecording() {
+	if app.capture.recording == nil {
+		app.capture.start(app.chip8.ROMFile())
+
+		return
+	}
+
+	app.capture.stop(func(path string, err error) {
+		if err != nil {
+			app.logger.Errorf("failed to save recording: %v", err)
+
+			return
+		}
+
+		app.logger.Infof("saved recording: %s", path)
+	})
 }
 
 func (app *App) Layout(outsideWith, outsideHeight int) (screenWidth, screenHeight int) {
-	return 64, 32
+	// the window is drawn at its full resolution, so text stays legible, and
+	// post-processing and the keypad have the pixels they need.
+	if app.picker != nil {
+		return outsideWith, outsideHeight
+	}
+
+	app.area = image.Rect(0, 0, outsideWith, outsideHeight)
+
+	if app.keypad != nil {
+		app.area.Max.X -= app.keypad.layout(outsideWith, outsideHeight)
+	}
+
+	if app.crt.enabled() {
+		app.crt.resize(app.area.Dx(), app.area.Dy(), 64, 32)
+	}
+
+	return outsideWith, outsideHeight
+}
+
+// drawScreen draws the screen into `app.area`.
+func (app *App) drawScreen(screen *ebiten.Image) {
+	screen.Fill(app.palette.Background())
+
+	op := &ebiten.DrawImageOptions{}
+
+	if app.crt.enabled() {
+		if app.crtImage == nil || app.crtImage.Bounds().Size() != app.area.Size() {
+			app.crtImage = ebiten.NewImage(app.area.Dx(), app.area.Dy())
+		}
+
+		app.crtImage.WritePixels(app.crt.pixels)
+		op.GeoM.Translate(float64(app.area.Min.X), float64(app.area.Min.Y))
+		screen.DrawImage(app.crtImage, op)
+
+		return
+	}
+
+	if app.image == nil {
+		app.image = ebiten.NewImage(64, 32)
+	}
+
+	app.image.WritePixels(app.pixels)
+
+	// scale to fit the area, keeping the aspect ratio.
+	scale := min(float64(app.area.Dx())/64, float64(app.area.Dy())/32)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(
+		float64(app.area.Min.X)+(float64(app.area.Dx())-64*scale)/2,
+		float64(app.area.Min.Y)+(float64(app.area.Dy())-32*scale)/2,
+	)
+	screen.DrawImage(app.image, op)
 }
 
 func (app *App) Run() error {
-	ebiten.SetWindowSize(640, 480)
-	ebiten.SetWindowTitle("chip-8")
+	if app.scale > 0 {
+		width, height := 64*app.scale, 32*app.scale
+
+		// the keypad is as wide as the screen is high.
+		if app.keypad != nil {
+			width += height
+		}
+
+		ebiten.SetWindowSize(width, height)
+	} else {
+		ebiten.SetWindowSize(640, 480)
+	}
+	ebiten.SetWindowTitle(app.title)
 
 	return ebiten.RunGame(app)
 }
@@ -0,0 +1,124 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/corani/chip-8/internal/keyboard"
+	"github.com/corani/chip-8/internal/palette"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// keypad is a panel beside the screen that shows the keys the ROM sees as
+// pressed, and that can be pressed with the mouse or by touch.
+type keypad struct {
+	area    image.Rectangle // of the whole panel
+	pressed map[uint8]bool  // keys held by a pointer
+}
+
+func newKeypad() *keypad {
+	return &keypad{pressed: make(map[uint8]bool)}
+}
+
+// layout places the keypad at the right of the window, and returns its width.
+func (k *keypad) layout(width, height int) int {
+	size := min(height, width/3)
+	top := (height - size) / 2
+
+	k.area = image.Rect(width-size, top, width, top+size)
+
+	return size
+}
+
+// key returns the area of the key at the given row and column.
+func (k *keypad) key(row, col int) image.Rectangle {
+	cell := k.area.Dx() / 4
+	gap := max(1, cell/10)
+
+	x := k.area.Min.X + col*cell
+	y := k.area.Min.Y + row*cell
+
+	return image.Rect(x+gap, y+gap, x+cell-gap, y+cell-gap)
+}
+
+// keyAt returns the key at the given position, if any.
+func (k *keypad) keyAt(x, y int) (uint8, bool) {
+	pt := image.Pt(x, y)
+
+	for row, codes := range keyboard.Layout {
+		for col, code := range codes {
+			if pt.In(k.key(row, col)) {
+				return code, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// pointers returns the keys under the mouse, while its button is pressed, and
+// under every touch.
+func (k *keypad) pointers() map[uint8]bool {
+	held := make(map[uint8]bool)
+
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		if code, ok := k.keyAt(ebiten.CursorPosition()); ok {
+			held[code] = true
+		}
+	}
+
+	for _, id := range ebiten.AppendTouchIDs(nil) {
+		if code, ok := k.keyAt(ebiten.TouchPosition(id)); ok {
+			held[code] = true
+		}
+	}
+
+	return held
+}
+
+// updateKeypad presses the keys that a pointer moved onto, and releases the
+// ones it left.
+func (app *App) updateKeypad() {
+	k := app.keypad
+	held := k.pointers()
+
+	for code := range held {
+		if !k.pressed[code] {
+			app.chip8.KeyDown(code)
+		}
+	}
+
+	for code := range k.pressed {
+		if !held[code] {
+			app.chip8.KeyUp(code)
+		}
+	}
+
+	k.pressed = held
+}
+
+// drawKeypad draws the keys, highlighting the ones the ROM sees as pressed.
+func (app *App) drawKeypad(screen *ebiten.Image) {
+	k := app.keypad
+	up := palette.Blend(app.palette.Background(), app.palette.Foreground(), 0x40)
+
+	for row, codes := range keyboard.Layout {
+		for col, code := range codes {
+			r := k.key(row, col)
+
+			c := up
+			if app.chip8.IsKeyPressed(code) {
+				c = app.palette.Foreground()
+			}
+
+			vector.DrawFilledRect(screen, float32(r.Min.X), float32(r.Min.Y),
+				float32(r.Dx()), float32(r.Dy()), c, false)
+
+			// the debug font is 6x16 pixels.
+			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%X", code),
+				r.Min.X+(r.Dx()-6)/2, r.Min.Y+(r.Dy()-lineHeight)/2)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package gui
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// namedKeys are the names of keys that don't depend on the keyboard layout.
+var namedKeys = map[ebiten.Key]string{
+	ebiten.KeyArrowUp: "up", ebiten.KeyArrowDown: "down",
+	ebiten.KeyArrowLeft: "left", ebiten.KeyArrowRight: "right",
+	ebiten.KeySpace: "space", ebiten.KeyEnter: "enter", ebiten.KeyNumpadEnter: "enter",
+	ebiten.KeyNumpadAdd: "+", ebiten.KeyNumpadSubtract: "-",
+	ebiten.KeyNumpadMultiply: "*", ebiten.KeyNumpadDivide: "/",
+	ebiten.KeyNumpadDecimal: ".",
+}
+
+// usKeys are the characters typed by punctuation keys on a US keyboard, for
+// when the layout is unknown.
+var usKeys = map[ebiten.Key]string{
+	ebiten.KeyComma: ",", ebiten.KeyPeriod: ".", ebiten.KeySlash: "/",
+	ebiten.KeySemicolon: ";", ebiten.KeyQuote: "'", ebiten.KeyMinus: "-",
+	ebiten.KeyEqual: "=", ebiten.KeyBracketLeft: "[", ebiten.KeyBracketRight: "]",
+	ebiten.KeyBackslash: "\\", ebiten.KeyBackquote: "`",
+}
+
+// keyName returns the name of the key in a keymap: what it types in the
+// current keyboard layout.
+func keyName(key ebiten.Key) string {
+	if name, ok := namedKeys[key]; ok {
+		return name
+	}
+
+	if name := ebiten.KeyName(key); name != "" {
+		return strings.ToLower(name)
+	}
+
+	if name, ok := usKeys[key]; ok {
+		return name
+	}
+
+	// without the layout, letters and digits are named after their position on
+	// a US keyboard, e.g. `Q`, `Digit1` or `Numpad1`.
+	name := key.String()
+	name = strings.TrimPrefix(name, "Digit")
+	name = strings.TrimPrefix(name, "Numpad")
+
+	return strings.ToLower(name)
+}
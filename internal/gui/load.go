@@ -0,0 +1,61 @@
+package gui
+
+import (
+	"image/color"
+
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/palette"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// loadROM loads a ROM that was picked or dropped, from `romfile` if it's a
+// real file or else from `rom`, and applies its settings.
+func (app *App) loadROM(name, romfile string, rom []uint8) error {
+	var err error
+
+	if romfile != "" {
+		err = app.chip8.LoadFile(romfile)
+	} else {
+		err = app.chip8.LoadROM(rom)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	app.logger.Infof("loaded rom: %s", name)
+
+	app.picker = nil
+	app.useConfig(app.forROM(name))
+	ebiten.SetWindowTitle(app.title)
+
+	if app.capture.recording != nil {
+		app.toggleRecording()
+	}
+
+	return nil
+}
+
+// useConfig applies the parts of the config that depend on the ROM.
+func (app *App) useConfig(cfg *config.Config) {
+	app.title = cfg.Title
+	app.keyMap = cfg.Keymap
+
+	app.palette = palette.Default()
+	if cfg.Palette != nil {
+		app.palette = *cfg.Palette
+	}
+
+	app.capture.palette = color.Palette{app.palette.Background(), app.palette.Foreground()}
+	if p := cfg.CapturePalette; p != nil {
+		app.capture.palette = color.Palette{p.Background(), p.Foreground()}
+	}
+
+	if app.beep != nil {
+		app.beep.setPitch(cfg.Sound)
+	}
+
+	for i, mapping := range padMappings(app.logger, cfg.Keys, cfg.Pads) {
+		app.pads[i].mapping = mapping
+	}
+}
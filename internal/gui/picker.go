@@ -0,0 +1,77 @@
+package gui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// lineHeight is the height of a line of text in the debug font.
+const lineHeight = 16
+
+func (app *App) updatePicker() error {
+	if chars := ebiten.AppendInputChars(nil); len(chars) > 0 {
+		app.picker.Type(string(chars))
+	}
+
+	page := app.pickerHeight()
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		return ebiten.Termination
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		app.picker.Move(-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		app.picker.Move(1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageUp):
+		app.picker.Move(-page)
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageDown):
+		app.picker.Move(page)
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		app.picker.Backspace()
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		romfile, ok := app.picker.Selected()
+		if !ok {
+			break
+		}
+
+		if err := app.loadROM(filepath.Base(romfile), romfile, nil); err != nil {
+			app.logger.Errorf("failed to load rom: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// pickerHeight is the number of ROMs that fit in the window.
+func (app *App) pickerHeight() int {
+	_, height := ebiten.WindowSize()
+
+	return max(1, height/lineHeight-3)
+}
+
+func (app *App) drawPicker(screen *ebiten.Image) {
+	screen.Fill(app.palette.Background())
+
+	ebitenutil.DebugPrintAt(screen, "Select a ROM (type to filter, enter to load, esc to quit)", 0, 0)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("> %s_", app.picker.Filter()), 0, lineHeight)
+
+	matches := app.picker.Matches()
+	start, end := app.picker.Window(app.pickerHeight())
+
+	for i := start; i < end; i++ {
+		prefix := "  "
+		if i == app.picker.Cursor() {
+			prefix = "> "
+		}
+
+		ebitenutil.DebugPrintAt(screen, prefix+matches[i], 0, (i-start+3)*lineHeight)
+	}
+
+	if len(matches) == 0 {
+		ebitenutil.DebugPrintAt(screen, "  (no roms found)", 0, 3*lineHeight)
+	}
+}
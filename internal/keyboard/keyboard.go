@@ -1,5 +1,13 @@
 package keyboard
 
+// Layout is the layout of the COSMAC VIP keypad, row by row.
+var Layout = [4][4]uint8{
+	{0x1, 0x2, 0x3, 0xC},
+	{0x4, 0x5, 0x6, 0xD},
+	{0x7, 0x8, 0x9, 0xE},
+	{0xA, 0x0, 0xB, 0xF},
+}
+
 func New() *Keyboard {
 	return &Keyboard{
 		pressed: nil,
@@ -31,6 +39,11 @@ func (k *Keyboard) KeyUp(code uint8) {
 	}
 }
 
+// Reset releases all keys.
+func (k *Keyboard) Reset() {
+	k.pressed = nil
+}
+
 func (k *Keyboard) IsKeyPressed(code uint8) bool {
 	for _, key := range k.pressed {
 		if key == code {
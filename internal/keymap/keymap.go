@@ -0,0 +1,235 @@
+package keymap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/corani/chip-8/internal/keyboard"
+)
+
+// Keymap maps the names of keys to CHIP-8 keys. Keys are named after what they
+// type on the keyboard, in lower case (e.g. `q`, `1` or `;`), or `up`, `down`,
+// `left`, `right`, `space` and `enter`. As the keys on the numeric keypad type
+// the same characters, they share their names with the other keys.
+type Keymap map[string]uint8
+
+// Layout is a named keymap, given as the keys in the same positions as the
+// COSMAC VIP keypad (see `keyboard.Layout`).
+type Layout struct {
+	Name string
+	Keys [4][4]string
+}
+
+// Layouts are the built-in layouts, which all use the 4x4 block of keys at the
+// top left of the keyboard, except for `numpad`. The first one is the default.
+var Layouts = []Layout{
+	{
+		Name: "qwerty",
+		Keys: [4][4]string{
+			{"1", "2", "3", "4"},
+			{"q", "w", "e", "r"},
+			{"a", "s", "d", "f"},
+			{"z", "x", "c", "v"},
+		},
+	},
+	{
+		Name: "azerty",
+		Keys: [4][4]string{
+			{"1", "2", "3", "4"},
+			{"a", "z", "e", "r"},
+			{"q", "s", "d", "f"},
+			{"w", "x", "c", "v"},
+		},
+	},
+	{
+		Name: "qwertz",
+		Keys: [4][4]string{
+			{"1", "2", "3", "4"},
+			{"q", "w", "e", "r"},
+			{"a", "s", "d", "f"},
+			{"y", "x", "c", "v"},
+		},
+	},
+	{
+		Name: "dvorak",
+		Keys: [4][4]string{
+			{"1", "2", "3", "4"},
+			{"'", ",", ".", "p"},
+			{"a", "o", "e", "u"},
+			{";", "q", "j", "k"},
+		},
+	},
+	{
+		Name: "numpad",
+		Keys: [4][4]string{
+			{"7", "8", "9", "/"},
+			{"4", "5", "6", "*"},
+			{"1", "2", "3", "-"},
+			{"0", ".", "enter", "+"},
+		},
+	},
+}
+
+// unshifted are the characters typed by the number row of an AZERTY keyboard
+// without shift, which are mapped like the digits.
+var unshifted = map[string]string{"&": "1", "é": "2", "\"": "3", "'": "4"}
+
+// Semantic are the keys used for the semantic keys of a ROM (`up`, `down`,
+// `left`, `right`, `a` and `b`), as found in the chip-8-database.
+var Semantic = map[string]string{
+	"up": "up", "down": "down", "left": "left", "right": "right",
+	"a": "space", "b": "enter",
+}
+
+// Default returns the keymap of the default layout.
+func Default() Keymap {
+	return Layouts[0].Keymap()
+}
+
+// Lookup returns the keymap of the layout with the given name.
+func Lookup(name string) (Keymap, bool) {
+	for _, l := range Layouts {
+		if l.Name == name {
+			return l.Keymap(), true
+		}
+	}
+
+	return nil, false
+}
+
+// Names returns the names of the layouts.
+func Names() []string {
+	names := make([]string, 0, len(Layouts))
+	for _, l := range Layouts {
+		names = append(names, l.Name)
+	}
+
+	return names
+}
+
+// Keymap returns the keymap of the layout.
+func (l Layout) Keymap() Keymap {
+	k := make(Keymap)
+
+	for row := range 4 {
+		for col := range 4 {
+			k[l.Keys[row][col]] = keyboard.Layout[row][col]
+		}
+	}
+
+	// on AZERTY keyboards, the digits need shift.
+	if l.Name == "azerty" {
+		for char, digit := range unshifted {
+			k[char] = k[digit]
+		}
+	}
+
+	return k
+}
+
+// Key returns the CHIP-8 key that the named key is mapped to.
+func (k Keymap) Key(name string) (uint8, bool) {
+	code, ok := k[strings.ToLower(name)]
+
+	return code, ok
+}
+
+// BindSemantic maps the keys used for the semantic keys of a ROM.
+func (k Keymap) BindSemantic(keys map[string]uint8) {
+	for name, code := range keys {
+		if key, ok := Semantic[name]; ok {
+			k[key] = code
+		}
+	}
+}
+
+// Mapping is a layout with keys bound on top of it. Both are optional.
+type Mapping struct {
+	Layout string
+	Keys   Keymap
+}
+
+// Resolve builds a keymap from the layout of the last mapping that has one
+// (or the default layout), the semantic keys of the ROM, and the keys of the
+// mappings in order, so later mappings take precedence.
+func Resolve(semantic map[string]uint8, mappings ...Mapping) Keymap {
+	k := Default()
+
+	for _, m := range mappings {
+		if layout, ok := Lookup(m.Layout); ok {
+			k = layout
+		}
+	}
+
+	k.BindSemantic(semantic)
+
+	for _, m := range mappings {
+		for name, code := range m.Keys {
+			k[name] = code
+		}
+	}
+
+	return k
+}
+
+// Parse parses a mapping: an optional layout name followed by `key=hex`
+// pairs, separated by commas, e.g. `azerty,space=5`.
+func Parse(s string) (Mapping, error) {
+	var m Mapping
+
+	if first, rest, _ := strings.Cut(s, ","); !strings.Contains(first, "=") {
+		if name := strings.TrimSpace(first); name != "" {
+			if _, ok := Lookup(name); !ok {
+				return Mapping{}, fmt.Errorf("unknown layout: %s (supported: %s)", name, strings.Join(Names(), ", "))
+			}
+
+			m.Layout = name
+		}
+
+		s = rest
+	}
+
+	keys, err := ParseKeys(s)
+	if err != nil {
+		return Mapping{}, err
+	}
+
+	m.Keys = keys
+
+	return m, nil
+}
+
+// ParseKeys parses `name=hex` pairs, separated by commas, e.g. `space=5,m=0`.
+// It's also used for the buttons of gamepads. The name is separated at the
+// last `=`, so `==5` binds the `=` key.
+func ParseKeys(s string) (Keymap, error) {
+	k := make(Keymap)
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+
+		if item == "" {
+			continue
+		}
+
+		i := strings.LastIndex(item, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid binding %q: expected name=hex", item)
+		}
+
+		name := strings.ToLower(strings.TrimSpace(item[:i]))
+		if name == "" {
+			return nil, fmt.Errorf("invalid binding %q: expected name=hex", item)
+		}
+
+		code, err := strconv.ParseUint(strings.TrimSpace(item[i+1:]), 16, 8)
+		if err != nil || code > 0xf {
+			return nil, fmt.Errorf("invalid binding %q: expected a hex digit", item)
+		}
+
+		k[name] = uint8(code)
+	}
+
+	return k, nil
+}
@@ -1,5 +1,7 @@
 package memory
 
+import "fmt"
+
 func New() *Memory {
 	return &Memory{}
 }
@@ -8,10 +10,22 @@ type Memory struct {
 	RAM [4096]byte
 }
 
-func (mem *Memory) Load(addr uint16, data []uint8) {
-	for i := 0; i < len(data); i++ {
-		mem.RAM[addr+uint16(i)] = data[i]
+// Load copies `data` into memory starting at `addr`, and returns an error if
+// it doesn't fit.
+func (mem *Memory) Load(addr uint16, data []uint8) error {
+	if int(addr)+len(data) > len(mem.RAM) {
+		return fmt.Errorf("%d bytes at %04x don't fit in %d bytes of memory",
+			len(data), addr, len(mem.RAM))
 	}
+
+	copy(mem.RAM[addr:], data)
+
+	return nil
+}
+
+// Clear sets all memory to zero.
+func (mem *Memory) Clear() {
+	mem.RAM = [4096]byte{}
 }
 
 func (mem *Memory) ReadByte(addr uint16) uint8 {
@@ -0,0 +1,151 @@
+package palette
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Palette holds the colors of the screen, indexed by the planes a pixel is set
+// in: the background, the first plane (foreground), the second plane, and
+// both planes. Only XO-CHIP uses the last two.
+type Palette struct {
+	Name   string
+	Colors [4]color.RGBA
+}
+
+func (p Palette) Background() color.RGBA {
+	return p.Colors[0]
+}
+
+func (p Palette) Foreground() color.RGBA {
+	return p.Colors[1]
+}
+
+// Pixel returns the color of a pixel with the given brightness (0-255), as
+// returned by the display.
+func (p Palette) Pixel(brightness uint8) color.RGBA {
+	return Blend(p.Colors[0], p.Colors[1], brightness)
+}
+
+// Themes are the built-in palettes. The first one is the default.
+var Themes = []Palette{
+	{
+		Name:   "classic",
+		Colors: [4]color.RGBA{rgb(0x000000), rgb(0xffffff), rgb(0xaaaaaa), rgb(0x555555)},
+	},
+	{
+		Name:   "amber",
+		Colors: [4]color.RGBA{rgb(0x1c1000), rgb(0xffb000), rgb(0xb36b00), rgb(0xffe0a0)},
+	},
+	{
+		Name:   "green",
+		Colors: [4]color.RGBA{rgb(0x001a00), rgb(0x33ff33), rgb(0x1a991a), rgb(0xb3ffb3)},
+	},
+	{
+		Name:   "lcd",
+		Colors: [4]color.RGBA{rgb(0x9bbc0f), rgb(0x0f380f), rgb(0x306230), rgb(0x8bac0f)},
+	},
+	{
+		// the defaults of Octo.
+		Name:   "octo",
+		Colors: [4]color.RGBA{rgb(0x996600), rgb(0xffcc00), rgb(0xff6600), rgb(0x662200)},
+	},
+}
+
+// Default returns the default theme.
+func Default() Palette {
+	return Themes[0]
+}
+
+// Lookup returns the theme with the given name.
+func Lookup(name string) (Palette, bool) {
+	for _, p := range Themes {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return Palette{}, false
+}
+
+// Names returns the names of the themes.
+func Names() []string {
+	names := make([]string, 0, len(Themes))
+	for _, p := range Themes {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
+
+// Parse returns the theme with the given name, or a palette from a list of
+// colors, e.g. `#000000,#ffffff`.
+func Parse(s string) (Palette, error) {
+	if p, ok := Lookup(s); ok {
+		return p, nil
+	}
+
+	if !strings.HasPrefix(s, "#") {
+		return Palette{}, fmt.Errorf("unknown theme: %s (available: %s)", s, strings.Join(Names(), ", "))
+	}
+
+	return FromHex(strings.Split(s, ",")...)
+}
+
+// FromHex creates a palette from the background color followed by up to three
+// foreground colors, as in the chip-8-database. Missing plane colors are mixed
+// from the background and foreground.
+func FromHex(colors ...string) (Palette, error) {
+	if len(colors) < 2 || len(colors) > 4 {
+		return Palette{}, fmt.Errorf("expected 2 to 4 colors, got %d", len(colors))
+	}
+
+	p := Palette{Name: strings.Join(colors, ",")}
+
+	for i, s := range colors {
+		c, err := ParseColor(s)
+		if err != nil {
+			return Palette{}, err
+		}
+
+		p.Colors[i] = c
+	}
+
+	blends := [4]uint8{2: 0xaa, 3: 0x55}
+
+	for i := len(colors); i < 4; i++ {
+		p.Colors[i] = Blend(p.Colors[0], p.Colors[1], blends[i])
+	}
+
+	return p, nil
+}
+
+// ParseColor parses a color in `#rrggbb` notation.
+func ParseColor(s string) (color.RGBA, error) {
+	c := color.RGBA{A: 255}
+
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("%q: %w", s, err)
+	}
+
+	return c, nil
+}
+
+// Hex formats a color in `#rrggbb` notation.
+func Hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Blend mixes the colors `a` and `b`, where `t` (0-255) is the amount of `b`.
+func Blend(a, b color.RGBA, t uint8) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8((int(a)*(255-int(t)) + int(b)*int(t)) / 255)
+	}
+
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+func rgb(hex uint32) color.RGBA {
+	return color.RGBA{uint8(hex >> 16), uint8(hex >> 8), uint8(hex), 0xff}
+}
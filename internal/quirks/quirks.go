@@ -0,0 +1,145 @@
+package quirks
+
+import "fmt"
+
+// Quirks are the behaviors that differ between CHIP-8 platforms. The names
+// match the ones used by the community chip-8-database.
+type Quirks struct {
+	// 8xy6/8xyE shift Vx in place, rather than shifting Vy into Vx.
+	Shift bool `json:"shift"`
+	// Fx55/Fx65 increment I by x, rather than by x+1.
+	MemoryIncrementByX bool `json:"memoryIncrementByX"`
+	// Fx55/Fx65 leave I unchanged.
+	MemoryLeaveIUnchanged bool `json:"memoryLeaveIUnchanged"`
+	// Sprites wrap around the edges of the screen, rather than being clipped.
+	Wrap bool `json:"wrap"`
+	// Bnnn jumps to nnn+Vx (where x is the high nibble of nnn), rather than
+	// nnn+V0.
+	Jump bool `json:"jump"`
+	// Dxyn waits for the vertical blank interrupt before drawing.
+	VBlank bool `json:"vblank"`
+	// 8xy1/8xy2/8xy3 reset VF to zero.
+	Logic bool `json:"logic"`
+}
+
+func (q Quirks) String() string {
+	return fmt.Sprintf("shift=%v memoryIncrementByX=%v memoryLeaveIUnchanged=%v wrap=%v jump=%v vblank=%v logic=%v",
+		q.Shift, q.MemoryIncrementByX, q.MemoryLeaveIUnchanged, q.Wrap, q.Jump, q.VBlank, q.Logic)
+}
+
+// Set changes a single quirk by its chip-8-database name.
+func (q *Quirks) Set(name string, value bool) error {
+	switch name {
+	case "shift":
+		q.Shift = value
+	case "memoryIncrementByX":
+		q.MemoryIncrementByX = value
+	case "memoryLeaveIUnchanged":
+		q.MemoryLeaveIUnchanged = value
+	case "wrap":
+		q.Wrap = value
+	case "jump":
+		q.Jump = value
+	case "vblank":
+		q.VBlank = value
+	case "logic":
+		q.Logic = value
+	default:
+		return fmt.Errorf("unknown quirk: %s", name)
+	}
+
+	return nil
+}
+
+// Platform is a named set of quirks.
+type Platform struct {
+	ID     string
+	Name   string
+	Quirks Quirks
+}
+
+// Platforms are the platforms from the chip-8-database that only differ in
+// their quirks.
+var Platforms = []Platform{
+	{
+		ID:   "originalChip8",
+		Name: "Cosmac VIP CHIP-8",
+		Quirks: Quirks{
+			VBlank: true,
+			Logic:  true,
+		},
+	},
+	{
+		ID:     "modernChip8",
+		Name:   "Modern CHIP-8",
+		Quirks: Quirks{},
+	},
+	{
+		ID:   "superchip",
+		Name: "Modern SUPER-CHIP",
+		Quirks: Quirks{
+			Shift:                 true,
+			MemoryLeaveIUnchanged: true,
+			Jump:                  true,
+		},
+	},
+	{
+		ID:   "xochip",
+		Name: "XO-CHIP",
+		Quirks: Quirks{
+			Wrap: true,
+		},
+	},
+}
+
+// Lookup returns the platform with the given ID.
+func Lookup(id string) (Platform, bool) {
+	for _, p := range Platforms {
+		if p.ID == id {
+			return p, true
+		}
+	}
+
+	return Platform{}, false
+}
+
+// Closest returns the platform whose quirks differ the least from `q`. Ties
+// are broken by the order of `Platforms`.
+func Closest(q Quirks) Platform {
+	best, bestDiff := Platforms[0], -1
+
+	for _, p := range Platforms {
+		diff := len(q.Diff(p.Quirks))
+
+		if bestDiff < 0 || diff < bestDiff {
+			best, bestDiff = p, diff
+		}
+	}
+
+	return best
+}
+
+// Diff returns the quirks in which `q` differs from `other`, as `name=value`
+// with the values of `q`.
+func (q Quirks) Diff(other Quirks) []string {
+	var diff []string
+
+	for _, f := range []struct {
+		name        string
+		value, want bool
+	}{
+		{"shift", q.Shift, other.Shift},
+		{"memoryIncrementByX", q.MemoryIncrementByX, other.MemoryIncrementByX},
+		{"memoryLeaveIUnchanged", q.MemoryLeaveIUnchanged, other.MemoryLeaveIUnchanged},
+		{"wrap", q.Wrap, other.Wrap},
+		{"jump", q.Jump, other.Jump},
+		{"vblank", q.VBlank, other.VBlank},
+		{"logic", q.Logic, other.Logic},
+	} {
+		if f.value != f.want {
+			diff = append(diff, fmt.Sprintf("%s=%v", f.name, f.value))
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,126 @@
+package romdb
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/corani/chip-8/internal/quirks"
+)
+
+// Colors are the colors a ROM was designed for. `Pixels` holds the background
+// color first, followed by the foreground color(s).
+type Colors struct {
+	Pixels  []string `json:"pixels"`
+	Buzzer  string   `json:"buzzer"`
+	Silence string   `json:"silence"`
+}
+
+// ROM is a single version of a program, as identified by its SHA-1 hash.
+type ROM struct {
+	File            string                     `json:"file"`
+	Platforms       []string                   `json:"platforms"`
+	QuirkyPlatforms map[string]map[string]bool `json:"quirkyPlatforms"`
+	Tickrate        uint                       `json:"tickrate"`
+	Keys            map[string]uint8           `json:"keys"`
+	Colors          *Colors                    `json:"colors"`
+}
+
+// Program is an entry in `programs.json` of the community chip-8-database.
+type Program struct {
+	Title   string         `json:"title"`
+	Authors []string       `json:"authors"`
+	ROMs    map[string]ROM `json:"roms"`
+}
+
+// Entry is the result of a lookup: the program and the matching ROM.
+type Entry struct {
+	Program
+	ROM
+}
+
+// Name returns the title of the program, including its authors if known.
+func (e Entry) Name() string {
+	if len(e.Authors) == 0 {
+		return e.Title
+	}
+
+	return fmt.Sprintf("%s by %s", e.Title, strings.Join(e.Authors, ", "))
+}
+
+// Platform returns the preferred platform of the ROM, with any ROM specific
+// deviations applied to its quirks.
+func (e Entry) Platform() (quirks.Platform, error) {
+	for _, id := range e.Platforms {
+		platform, ok := quirks.Lookup(id)
+		if !ok {
+			continue
+		}
+
+		for name, value := range e.QuirkyPlatforms[id] {
+			if err := platform.Quirks.Set(name, value); err != nil {
+				return platform, err
+			}
+		}
+
+		return platform, nil
+	}
+
+	return quirks.Platform{}, fmt.Errorf("unsupported platforms: %s", strings.Join(e.Platforms, ", "))
+}
+
+type Database struct {
+	entries map[string]Entry
+}
+
+// Load reads the database from `programs.json`. The path can either point to
+// the file itself, or to the directory that contains it.
+func Load(path string) (*Database, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "programs.json")
+	}
+
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var programs []Program
+
+	if err := json.Unmarshal(bs, &programs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	db := &Database{
+		entries: map[string]Entry{},
+	}
+
+	for _, program := range programs {
+		for hash, rom := range program.ROMs {
+			db.entries[strings.ToLower(hash)] = Entry{
+				Program: program,
+				ROM:     rom,
+			}
+		}
+	}
+
+	return db, nil
+}
+
+// Hash returns the SHA-1 hash of the ROM, as used by the database.
+func Hash(rom []uint8) string {
+	sum := sha1.Sum(rom)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Lookup finds the ROM in the database.
+func (db *Database) Lookup(rom []uint8) (Entry, bool) {
+	entry, ok := db.entries[Hash(rom)]
+
+	return entry, ok
+}
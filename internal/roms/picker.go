@@ -0,0 +1,103 @@
+package roms
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// NewPicker creates a picker for the ROMs in `root`.
+func NewPicker(root string) (*Picker, error) {
+	roms, err := Find(root)
+	if err != nil {
+		return nil, err
+	}
+
+	picker := &Picker{
+		root: root,
+		roms: roms,
+	}
+
+	picker.update()
+
+	return picker, nil
+}
+
+// Picker holds the state of a ROM browser: the ROMs found in a directory, the
+// filter typed by the user and the selected ROM. The user interfaces only
+// render it and forward key presses.
+type Picker struct {
+	root    string
+	roms    []string
+	filter  string
+	matches []string
+	cursor  int
+}
+
+// Filter returns the text the ROMs are filtered by.
+func (p *Picker) Filter() string {
+	return p.filter
+}
+
+// Matches returns the ROMs that match the filter.
+func (p *Picker) Matches() []string {
+	return p.matches
+}
+
+// Cursor returns the index of the selected ROM in `Matches`.
+func (p *Picker) Cursor() int {
+	return p.cursor
+}
+
+// Type appends text to the filter.
+func (p *Picker) Type(text string) {
+	p.filter += text
+	p.update()
+}
+
+// Backspace removes the last character from the filter.
+func (p *Picker) Backspace() {
+	if p.filter == "" {
+		return
+	}
+
+	runes := []rune(p.filter)
+	p.filter = string(runes[:len(runes)-1])
+	p.update()
+}
+
+// Move moves the cursor by `delta` entries, staying within the matches.
+func (p *Picker) Move(delta int) {
+	p.cursor = max(0, min(len(p.matches)-1, p.cursor+delta))
+}
+
+// Selected returns the full path of the selected ROM.
+func (p *Picker) Selected() (string, bool) {
+	if len(p.matches) == 0 {
+		return "", false
+	}
+
+	return filepath.Join(p.root, p.matches[p.cursor]), true
+}
+
+// Window returns the range of matches to show if only `height` lines fit,
+// keeping the cursor in view.
+func (p *Picker) Window(height int) (int, int) {
+	start := max(0, min(p.cursor-height/2, len(p.matches)-height))
+
+	return start, min(len(p.matches), start+height)
+}
+
+// update filters the ROMs by name, case insensitive.
+func (p *Picker) update() {
+	filter := strings.ToLower(p.filter)
+
+	p.matches = p.matches[:0]
+
+	for _, rom := range p.roms {
+		if strings.Contains(strings.ToLower(rom), filter) {
+			p.matches = append(p.matches, rom)
+		}
+	}
+
+	p.Move(0)
+}
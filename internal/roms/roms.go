@@ -0,0 +1,67 @@
+package roms
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Extensions are the file extensions of CHIP-8, SUPER-CHIP and XO-CHIP ROMs.
+var Extensions = []string{".ch8", ".sc8", ".xo8"}
+
+// ProjectRoot finds the root folder of the project (the one with `go.mod`),
+// starting from the working directory.
+func ProjectRoot() (string, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		info, err := os.Stat(filepath.Join(root, "go.mod"))
+		if err == nil && !info.IsDir() {
+			return root, nil
+		}
+
+		if filepath.Dir(root) == root {
+			return "", errors.New("could not find project root")
+		}
+
+		root = filepath.Dir(root)
+	}
+}
+
+// Find recursively searches `root` for ROM files, and returns their paths
+// relative to `root` in alphabetical order. Directories that can't be read
+// are skipped.
+func Find(root string) ([]string, error) {
+	var roms []string
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if slices.Contains(Extensions, strings.ToLower(filepath.Ext(path))) {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+
+			roms = append(roms, filepath.ToSlash(rel))
+		}
+
+		return nil
+	})
+
+	sort.Strings(roms)
+
+	return roms, err
+}
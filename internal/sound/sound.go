@@ -18,6 +18,11 @@ func (s *Sound) Tick(dt time.Duration) {
 	s.timer.Tick(dt)
 }
 
+// Reset silences the sound.
+func (s *Sound) Reset() {
+	s.timer.Reset()
+}
+
 func (s *Sound) SetActive(duration uint8) {
 	s.timer.Set(duration)
 }
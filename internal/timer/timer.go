@@ -31,6 +31,12 @@ func (t *Timer) Tick(dt time.Duration) {
 	}
 }
 
+// Reset stops the timer.
+func (t *Timer) Reset() {
+	t.count = 0
+	t.dt = 0
+}
+
 func (t *Timer) Set(c uint8) {
 	t.count = c
 }
@@ -0,0 +1,26 @@
+package tui
+
+import (
+	"os"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/corani/chip-8/internal/cast"
+)
+
+// startCast records the session to the asciinema file at `path`.
+func (app *App) startCast(path string) {
+	width, height := 80, 24
+	if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil {
+		width, height = w, h
+	}
+
+	rec, err := cast.Create(path, width, height, app.title)
+	if err != nil {
+		app.log.Errorf("failed to start recording: %v", err)
+
+		return
+	}
+
+	app.cast = rec
+	app.output.rec = rec
+}
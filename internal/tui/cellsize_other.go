@@ -0,0 +1,9 @@
+//go:build !unix
+
+package tui
+
+// cellSize returns a reasonable default for the size of a terminal cell in
+// pixels, as it can't be queried on this platform.
+func cellSize() (int, int) {
+	return 8, 16
+}
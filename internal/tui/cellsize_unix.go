@@ -0,0 +1,20 @@
+//go:build unix
+
+package tui
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cellSize returns the size of a terminal cell in pixels, if the terminal
+// reports it, or a reasonable default otherwise.
+func cellSize() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+		return 8, 16
+	}
+
+	return int(ws.Xpixel / ws.Col), int(ws.Ypixel / ws.Row)
+}
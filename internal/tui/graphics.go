@@ -0,0 +1,174 @@
+package tui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/palette"
+)
+
+// graphics renders the framebuffer as an image, using either the kitty
+// graphics protocol or sixel, scaled to the size of the terminal.
+type graphics struct {
+	protocol string // `kitty` or `sixel`
+	cols     int    // terminal width in cells
+	rows     int    // terminal height in cells, excluding the status line
+	palette  palette.Palette
+}
+
+// isGraphics checks whether `name` selects one of the image renderers.
+// `graphics` picks whichever protocol the terminal supports.
+func isGraphics(name string) bool {
+	return name == "graphics" || name == "kitty" || name == "sixel"
+}
+
+// initGraphics sets up the image renderer selected by `cfg.Renderer`, falling
+// back to half blocks when the terminal doesn't advertise support for it.
+func (app *App) initGraphics(cfg *config.Config, protocol string) {
+	app.render = renderHalf
+
+	switch {
+	case protocol == "":
+		app.log.Warnf("terminal doesn't support graphics, falling back to text")
+
+		return
+	case cfg.Renderer != "graphics" && cfg.Renderer != protocol:
+		// a kitty terminal might still support sixel, but it only advertises
+		// one of them, so stick to what we know works.
+		app.log.Warnf("terminal doesn't support %s, using %s", cfg.Renderer, protocol)
+	}
+
+	g := &graphics{
+		protocol: protocol,
+		cols:     80,
+		rows:     23,
+		palette:  palette.Default(),
+	}
+
+	if cols, rows, err := term.GetSize(os.Stdout.Fd()); err == nil {
+		g.cols, g.rows = cols, rows-1
+	}
+
+	app.graphics = g
+
+	if protocol == "kitty" {
+		app.render = g.renderKitty
+	} else {
+		app.render = g.renderSixel
+	}
+}
+
+// kittyChunk is the maximum size of a single chunk of image data.
+const kittyChunk = 4096
+
+// renderKitty transmits the framebuffer as a 24-bit RGB image and places it
+// over the available cells. The terminal does the scaling.
+func (g *graphics) renderKitty(sb *strings.Builder, fb [][]uint8) {
+	width, height := len(fb), len(fb[0])
+
+	// cells are about twice as high as they are wide.
+	rows := max(1, min(g.rows, g.cols*height/width/2))
+	cols := rows * 2 * width / height
+
+	data := make([]byte, 0, width*height*3)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := g.palette.Pixel(fb[x][y])
+
+			data = append(data, c.R, c.G, c.B)
+		}
+	}
+
+	payload := base64.StdEncoding.EncodeToString(data)
+
+	for i := 0; i < len(payload); i += kittyChunk {
+		chunk := payload[i:min(len(payload), i+kittyChunk)]
+
+		more := 0
+		if i+kittyChunk < len(payload) {
+			more = 1
+		}
+
+		// the image and placement ids are fixed, so every frame replaces the
+		// previous one. C=1 keeps the cursor in place.
+		if i == 0 {
+			fmt.Fprintf(sb, "\x1b_Ga=T,i=1,p=1,f=24,s=%d,v=%d,c=%d,r=%d,C=1,q=2,m=%d;%s\x1b\\",
+				width, height, cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(sb, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+
+	// reserve the lines covered by the image.
+	sb.WriteString(strings.Repeat("\n", rows))
+}
+
+// renderSixel draws the framebuffer as a sixel image, scaled by the largest
+// integer factor that fits the terminal.
+func (g *graphics) renderSixel(sb *strings.Builder, fb [][]uint8) {
+	width, height := len(fb), len(fb[0])
+	cellWidth, cellHeight := cellSize()
+
+	scale := max(1, min(g.cols*cellWidth/width, g.rows*cellHeight/height))
+	pw, ph := width*scale, height*scale
+
+	// save the cursor, so the image doesn't move it.
+	sb.WriteString("\x1b7\x1bPq")
+	fmt.Fprintf(sb, "\"1;1;%d;%d", pw, ph)
+
+	for i, c := range []color.RGBA{g.palette.Background(), g.palette.Foreground()} {
+		fmt.Fprintf(sb, "#%d;2;%d;%d;%d", i, int(c.R)*100/255, int(c.G)*100/255, int(c.B)*100/255)
+	}
+
+	// each sixel is a column of six pixels.
+	for band := 0; band < ph; band += 6 {
+		for i, on := range []bool{false, true} {
+			fmt.Fprintf(sb, "#%d", i)
+
+			var run byte
+			count := 0
+
+			flush := func() {
+				if count > 3 {
+					fmt.Fprintf(sb, "!%d%c", count, run)
+				} else {
+					sb.WriteString(strings.Repeat(string(run), count))
+				}
+			}
+
+			for px := 0; px < pw; px++ {
+				bits := byte(0)
+
+				for bit := 0; bit < 6 && band+bit < ph; bit++ {
+					if pixel(fb, px/scale, (band+bit)/scale) == on {
+						bits |= 1 << bit
+					}
+				}
+
+				if ch := 63 + bits; ch == run {
+					count++
+				} else {
+					flush()
+
+					run, count = ch, 1
+				}
+			}
+
+			flush()
+			sb.WriteByte('$')
+		}
+
+		sb.WriteByte('-')
+	}
+
+	sb.WriteString("\x1b\\\x1b8")
+
+	// reserve the lines covered by the image.
+	sb.WriteString(strings.Repeat("\n", (ph+cellHeight-1)/cellHeight))
+}
@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyboardFlags enables the kitty keyboard protocol: disambiguate escape codes
+// (1), report event types (2), report alternate keys (4) and report all keys
+// as escape codes (8), so that keys that produce text report releases too.
+const keyboardFlags = 1 | 2 | 4 | 8
+
+// Event types of the kitty keyboard protocol.
+const (
+	keyPress   = 1
+	keyRepeat  = 2
+	keyRelease = 3
+)
+
+// Modifier bits of the kitty keyboard protocol.
+const (
+	modShift = 1 << 0
+	modAlt   = 1 << 1
+	modCtrl  = 1 << 2
+)
+
+// keyReleaseMsg is sent when a key is released, which the terminal only
+// reports when the kitty keyboard protocol is enabled.
+type keyReleaseMsg tea.KeyMsg
+
+// kittyKeyRe matches `CSI code[:shifted] ; modifiers[:event] [; text] final`.
+var kittyKeyRe = regexp.MustCompile(`^\x1b\[([\d:]*)(?:;(\d*)(?::(\d))?)?(?:;[\d:]*)?([u~ABCDFHPQS])$`)
+
+// kittyKeys are the keys with special key codes in `CSI code u`.
+var kittyKeys = map[int]tea.KeyType{
+	9: tea.KeyTab, 13: tea.KeyEnter, 27: tea.KeyEscape, 32: tea.KeySpace,
+	127: tea.KeyBackspace, 57414: tea.KeyEnter,
+}
+
+// tildeKeys are the keys reported as `CSI number ~`.
+var tildeKeys = map[int]tea.KeyType{
+	2: tea.KeyInsert, 3: tea.KeyDelete, 5: tea.KeyPgUp, 6: tea.KeyPgDown,
+	13: tea.KeyF3, 15: tea.KeyF5, 17: tea.KeyF6, 18: tea.KeyF7, 19: tea.KeyF8,
+	20: tea.KeyF9, 21: tea.KeyF10, 23: tea.KeyF11, 24: tea.KeyF12,
+}
+
+// letterKeys are the keys reported as `CSI 1 letter`.
+var letterKeys = map[byte]tea.KeyType{
+	'A': tea.KeyUp, 'B': tea.KeyDown, 'C': tea.KeyRight, 'D': tea.KeyLeft,
+	'F': tea.KeyEnd, 'H': tea.KeyHome, 'P': tea.KeyF1, 'Q': tea.KeyF2,
+	'S': tea.KeyF4,
+}
+
+// kpDigits are the key codes of the keypad digits `0` to `9`.
+const (
+	kp0 = 57399
+	kp9 = 57408
+)
+
+// parseKittyKey converts a key event reported with the kitty keyboard
+// protocol into a bubbletea key, along with the event type.
+func parseKittyKey(msg tea.Msg) (tea.KeyMsg, int, bool) {
+	// bubbletea reports the sequences it doesn't understand as an unexported
+	// `[]byte` type, so that's the only way to get at them.
+	v := reflect.ValueOf(msg)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 {
+		return tea.KeyMsg{}, 0, false
+	}
+
+	m := kittyKeyRe.FindSubmatch(v.Bytes())
+	if m == nil {
+		return tea.KeyMsg{}, 0, false
+	}
+
+	codes := strings.Split(string(m[1]), ":")
+	code, _ := strconv.Atoi(codes[0])
+
+	mods, event := 0, keyPress
+	if n, err := strconv.Atoi(string(m[2])); err == nil {
+		mods = n - 1
+	}
+
+	if n, err := strconv.Atoi(string(m[3])); err == nil {
+		event = n
+	}
+
+	var key tea.Key
+
+	switch final := m[4][0]; final {
+	case 'u':
+		if typ, ok := kittyKeys[code]; ok {
+			key.Type = typ
+		} else if code >= kp0 && code <= kp9 {
+			key = tea.Key{Type: tea.KeyRunes, Runes: []rune{rune('0' + code - kp0)}}
+		} else if code < 32 || code >= 57344 {
+			// control characters and the private use area, which holds the
+			// modifier keys among others.
+			return tea.KeyMsg{}, 0, false
+		} else if r := rune(code); mods&modCtrl != 0 && r >= 'a' && r <= 'z' {
+			key.Type = tea.KeyCtrlA + tea.KeyType(r-'a')
+		} else {
+			if mods&modShift != 0 {
+				r = unicode.ToUpper(r)
+
+				if len(codes) > 1 && codes[1] != "" {
+					shifted, _ := strconv.Atoi(codes[1])
+					r = rune(shifted)
+				}
+			}
+
+			key = tea.Key{Type: tea.KeyRunes, Runes: []rune{r}}
+		}
+
+		if key.Type == tea.KeySpace {
+			key.Runes = []rune{' '}
+		}
+	case '~':
+		typ, ok := tildeKeys[code]
+		if !ok {
+			return tea.KeyMsg{}, 0, false
+		}
+
+		key.Type = typ
+	default:
+		key.Type = letterKeys[final]
+	}
+
+	key.Alt = mods&modAlt != 0
+
+	return tea.KeyMsg(key), event, true
+}
+
+// keyName returns the name of the key in a keymap.
+func keyName(msg tea.KeyMsg) string {
+	if name := msg.String(); name != " " {
+		return name
+	}
+
+	return "space"
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/corani/chip-8/internal/keyboard"
+)
+
+// Size of a key of the keypad in cells, and the gap between keys.
+const (
+	keyWidth  = 5
+	keyHeight = 3
+	keyGap    = 1 // columns between keys, rows are separated by an empty line
+	padMargin = 2 // between the screen and the keypad
+)
+
+// keypad is a clickable keypad drawn beside the screen.
+type keypad struct {
+	up, down lipgloss.Style
+	left     int   // column of the keypad, set when rendering
+	held     uint8 // key held with the mouse
+	holding  bool
+}
+
+func newKeypad() *keypad {
+	key := lipgloss.NewStyle().
+		Width(keyWidth).
+		Height(keyHeight).
+		Align(lipgloss.Center, lipgloss.Center)
+
+	return &keypad{
+		up:   key.Background(lipgloss.Color("8")).Foreground(lipgloss.Color("15")),
+		down: key.Background(lipgloss.Color("15")).Foreground(lipgloss.Color("0")),
+	}
+}
+
+// keyAt returns the key at the given cell, if any.
+func (k *keypad) keyAt(x, y int) (uint8, bool) {
+	x -= k.left
+	if x < 0 || y < 0 || x%(keyWidth+keyGap) >= keyWidth || y%(keyHeight+keyGap) >= keyHeight {
+		return 0, false
+	}
+
+	col, row := x/(keyWidth+keyGap), y/(keyHeight+keyGap)
+	if col >= 4 || row >= 4 {
+		return 0, false
+	}
+
+	return keyboard.Layout[row][col], true
+}
+
+// updateKeypad presses and releases keys with the mouse.
+func (app *App) updateKeypad(msg tea.MouseMsg) {
+	k := app.keypad
+
+	switch {
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft:
+		if code, ok := k.keyAt(msg.X, msg.Y); ok {
+			app.chip8.KeyDown(code)
+			k.held, k.holding = code, true
+		}
+	case msg.Action == tea.MouseActionRelease && k.holding:
+		app.chip8.KeyUp(k.held)
+		k.holding = false
+	}
+}
+
+// viewKeypad draws the keypad to the right of the screen, highlighting the
+// keys that the ROM sees as pressed.
+func (app *App) viewKeypad(screen string) string {
+	k := app.keypad
+	k.left = lipgloss.Width(screen) + padMargin
+
+	rows := make([]string, 0, 2*len(keyboard.Layout))
+
+	for i, codes := range keyboard.Layout {
+		keys := make([]string, 0, 2*len(codes))
+
+		for j, code := range codes {
+			style := k.up
+			if app.chip8.IsKeyPressed(code) {
+				style = k.down
+			}
+
+			if j > 0 {
+				keys = append(keys, strings.Repeat(" ", keyGap))
+			}
+
+			keys = append(keys, style.Render(fmt.Sprintf("%X", code)))
+		}
+
+		if i > 0 {
+			rows = append(rows, "") // an empty line between the rows
+		}
+
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, keys...))
+	}
+
+	pad := lipgloss.JoinVertical(lipgloss.Left, rows...)
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, screen, strings.Repeat(" ", padMargin), pad)
+}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/palette"
+)
+
+// loadROM loads the ROM that was picked from `romfile`, and applies its
+// settings.
+func (app *App) loadROM(name, romfile string) (tea.Cmd, error) {
+	if err := app.chip8.LoadFile(romfile); err != nil {
+		return nil, err
+	}
+
+	app.log.Infof("loaded rom: %s", name)
+
+	app.picker = nil
+	app.dt = time.Now()
+	app.useConfig(app.forROM(name))
+
+	return tea.SetWindowTitle(app.title), nil
+}
+
+// useConfig applies the parts of the config that depend on the ROM.
+func (app *App) useConfig(cfg *config.Config) {
+	app.title = cfg.Title
+	app.keyMap = cfg.Keymap
+	app.style = lipgloss.NewStyle()
+
+	if app.graphics != nil {
+		app.graphics.palette = palette.Default()
+	}
+
+	// without a palette, the text renderers use the colors of the terminal.
+	if p := cfg.Palette; p != nil {
+		app.style = app.style.
+			Background(lipgloss.Color(palette.Hex(p.Background()))).
+			Foreground(lipgloss.Color(palette.Hex(p.Foreground())))
+
+		if app.graphics != nil {
+			app.graphics.palette = *p
+		}
+	}
+}
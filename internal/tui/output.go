@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"github.com/corani/chip-8/internal/cast"
+)
+
+const (
+	exitAltScreen = "\x1b[?1049l"
+	popKeyboard   = "\x1b[<u"
+)
+
+// terminal is the output of the program. It serializes the writes, so that
+// escape codes written by commands don't end up in the middle of a frame, and
+// copies everything into the recording, if there is one. It embeds the
+// terminal, so that bubbletea can still control it.
+type terminal struct {
+	*os.File
+	log *log.Logger
+	rec *cast.Recorder
+
+	mu sync.Mutex
+	// keyboard is set while the kitty keyboard protocol flags are pushed.
+	keyboard bool
+}
+
+func newTerminal(log *log.Logger) *terminal {
+	return &terminal{File: os.Stdout, log: log}
+}
+
+func (t *terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// The alternate screen has its own stack of keyboard flags, so they are
+	// popped right before bubbletea leaves it, however the program exits.
+	if t.keyboard && bytes.Contains(p, []byte(exitAltScreen)) {
+		t.keyboard = false
+
+		if _, err := t.write([]byte(popKeyboard)); err != nil {
+			return 0, err
+		}
+	}
+
+	return t.write(p)
+}
+
+// WriteString shadows the method of the embedded file, which the renderer would
+// otherwise use to write escape codes.
+func (t *terminal) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
+
+// pushKeyboard pushes the kitty keyboard protocol flags.
+func (t *terminal) pushKeyboard(flags int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := t.write(fmt.Appendf(nil, "\x1b[>%du", flags)); err != nil {
+		return err
+	}
+
+	t.keyboard = true
+
+	return nil
+}
+
+func (t *terminal) write(p []byte) (int, error) {
+	n, err := t.File.Write(p)
+
+	if n > 0 && t.rec != nil {
+		if _, err := t.rec.Write(p[:n]); err != nil {
+			t.log.Errorf("failed to record: %v", err)
+		}
+	}
+
+	return n, err
+}
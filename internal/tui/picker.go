@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (app *App) updatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		// keep the tick loop going, so the emulator starts as soon as a ROM
+		// was selected.
+		if _, ok := msg.(tickMsg); ok {
+			return app, tick
+		}
+
+		return app, nil
+	}
+
+	switch key.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return app, tea.Quit
+	case tea.KeyUp, tea.KeyCtrlP:
+		app.picker.Move(-1)
+	case tea.KeyDown, tea.KeyCtrlN:
+		app.picker.Move(1)
+	case tea.KeyPgUp:
+		app.picker.Move(-app.pickerHeight())
+	case tea.KeyPgDown:
+		app.picker.Move(app.pickerHeight())
+	case tea.KeyBackspace:
+		app.picker.Backspace()
+	case tea.KeySpace:
+		app.picker.Type(" ")
+	case tea.KeyRunes:
+		app.picker.Type(string(key.Runes))
+	case tea.KeyEnter:
+		romfile, ok := app.picker.Selected()
+		if !ok {
+			break
+		}
+
+		cmd, err := app.loadROM(filepath.Base(romfile), romfile)
+		if err != nil {
+			app.log.Errorf("failed to load rom: %v", err)
+
+			break
+		}
+
+		return app, cmd
+	}
+
+	return app, nil
+}
+
+// pickerHeight is the number of ROMs that fit on the screen.
+func (app *App) pickerHeight() int {
+	if app.height <= 3 {
+		return 20
+	}
+
+	return app.height - 3
+}
+
+func (app *App) viewPicker() string {
+	app.view.Reset()
+
+	app.view.WriteString("Select a ROM (type to filter, enter to load, esc to quit)\n")
+	fmt.Fprintf(&app.view, "> %s_\n\n", app.picker.Filter())
+
+	matches := app.picker.Matches()
+	start, end := app.picker.Window(app.pickerHeight())
+
+	for i := start; i < end; i++ {
+		if i == app.picker.Cursor() {
+			fmt.Fprintf(&app.view, "> %s\n", matches[i])
+		} else {
+			fmt.Fprintf(&app.view, "  %s\n", matches[i])
+		}
+	}
+
+	if len(matches) == 0 {
+		app.view.WriteString("  (no roms found)\n")
+	}
+
+	return app.view.String()
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"strings"
+)
+
+// renderer writes the framebuffer to `sb` as text, one line per row of cells.
+type renderer func(sb *strings.Builder, fb [][]uint8)
+
+// renderers are the available ways to draw the framebuffer in a terminal.
+var renderers = map[string]renderer{
+	"block":   renderBlock,
+	"half":    renderHalf,
+	"braille": renderBraille,
+}
+
+// pixel returns whether the pixel at (x, y) is lit at all, treating pixels
+// outside the framebuffer as not lit.
+func pixel(fb [][]uint8, x, y int) bool {
+	return x < len(fb) && y < len(fb[x]) && fb[x][y] != 0
+}
+
+// renderBlock uses a full block per pixel, so each pixel is a cell.
+func renderBlock(sb *strings.Builder, fb [][]uint8) {
+	for y := 0; y < len(fb[0]); y++ {
+		for x := 0; x < len(fb); x++ {
+			if pixel(fb, x, y) {
+				sb.WriteRune('█')
+			} else {
+				sb.WriteRune(' ')
+			}
+		}
+
+		sb.WriteRune('\n')
+	}
+}
+
+// renderHalf uses upper and lower half blocks, so each cell holds two pixels
+// on top of each other. As cells are about twice as high as they are wide,
+// this results in square pixels.
+func renderHalf(sb *strings.Builder, fb [][]uint8) {
+	for y := 0; y < len(fb[0]); y += 2 {
+		for x := 0; x < len(fb); x++ {
+			top, bottom := pixel(fb, x, y), pixel(fb, x, y+1)
+
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+
+		sb.WriteRune('\n')
+	}
+}
+
+// brailleDots are the bits of the braille dots, indexed by [y][x] within a
+// cell of 2x4 pixels.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// renderBraille uses braille patterns, so each cell holds 2x4 pixels.
+func renderBraille(sb *strings.Builder, fb [][]uint8) {
+	for y := 0; y < len(fb[0]); y += 4 {
+		for x := 0; x < len(fb); x += 2 {
+			r := rune(0x2800)
+
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					if pixel(fb, x+dx, y+dy) {
+						r |= brailleDots[dy][dx]
+					}
+				}
+			}
+
+			sb.WriteRune(r)
+		}
+
+		sb.WriteRune('\n')
+	}
+}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/corani/chip-8/internal/quirks"
+)
+
+// statusInterval is how often the speed in the status line is measured.
+const statusInterval = time.Second
+
+// status measures the actual speed of the emulator for the status line.
+type status struct {
+	style  lipgloss.Style
+	bell   bool // ring the terminal bell when the sound starts
+	beep   bool // the sound timer was active on the last frame
+	start  time.Time
+	cycles uint64 // cycles at `start`
+	frames int    // frames since `start`
+	ips    float64
+	fps    float64
+}
+
+// updateStatus counts a frame and measures the speed once per interval. It
+// also rings the bell when the sound starts, if enabled.
+func (app *App) updateStatus(now time.Time) {
+	s := &app.status
+	s.frames++
+
+	if elapsed := now.Sub(s.start); elapsed >= statusInterval {
+		cycles := app.chip8.Cycles()
+
+		s.ips = float64(cycles-s.cycles) / elapsed.Seconds()
+		s.fps = float64(s.frames) / elapsed.Seconds()
+		s.start, s.cycles, s.frames = now, cycles, 0
+	}
+
+	beep := app.chip8.Beeping()
+	if beep && !s.beep && s.bell {
+		fmt.Fprint(app.output, "\a")
+	}
+
+	s.beep = beep
+}
+
+// viewStatus renders the status line below the screen.
+func (app *App) viewStatus() string {
+	s := &app.status
+
+	name := filepath.Base(app.chip8.ROMFile())
+
+	// the quirk profile is marked if it doesn't exactly match a platform.
+	q := app.chip8.Quirks()
+	profile := quirks.Closest(q)
+
+	platform := profile.ID
+	if profile.Quirks != q {
+		platform += "*"
+	}
+
+	fields := []string{
+		name,
+		fmt.Sprintf("%.0f ips", s.ips),
+		fmt.Sprintf("%.0f fps", s.fps),
+		platform,
+	}
+
+	if mode := app.chip8.Mode(); mode != "" {
+		fields = append(fields, mode)
+	}
+
+	if s.beep {
+		fields = append(fields, "♪ beep")
+	}
+
+	return s.style.Render(strings.Join(fields, " │ "))
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/cancelreader"
+)
+
+// capabilities are the optional features that the terminal advertises.
+type capabilities struct {
+	// graphics is the preferred image protocol (`kitty` or `sixel`), if any.
+	graphics string
+	// keyboard is set if the terminal supports the kitty keyboard protocol.
+	keyboard bool
+}
+
+// keyboardFlagsRe matches the reply to the kitty keyboard protocol query.
+var keyboardFlagsRe = regexp.MustCompile(`\x1b\[\?\d*u`)
+
+// detect asks the terminal which features it supports. Terminals that don't
+// understand a query ignore it, so each query is followed by the primary
+// device attributes, which every terminal answers.
+func detect() capabilities {
+	var caps capabilities
+
+	response := query("\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\" + // kitty graphics
+		"\x1b[?u" + // kitty keyboard
+		"\x1b[c") // primary device attributes
+
+	switch {
+	case bytes.Contains(response, []byte("\x1b_Gi=31;OK")):
+		caps.graphics = "kitty"
+	case hasAttribute(response, "4"):
+		caps.graphics = "sixel"
+	}
+
+	caps.keyboard = keyboardFlagsRe.Match(response)
+
+	return caps
+}
+
+// query writes `q` to the terminal and collects the response until the reply
+// to the primary device attributes arrives, or a timeout expires.
+func query(q string) []byte {
+	fd := os.Stdin.Fd()
+
+	if !term.IsTerminal(fd) || !term.IsTerminal(os.Stdout.Fd()) {
+		return nil
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil
+	}
+	defer term.Restore(fd, state) //nolint:errcheck
+
+	reader, err := cancelreader.NewReader(os.Stdin)
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	if _, err := os.Stdout.WriteString(q); err != nil {
+		return nil
+	}
+
+	timer := time.AfterFunc(200*time.Millisecond, func() { reader.Cancel() })
+	defer timer.Stop()
+
+	var response []byte
+
+	buf := make([]byte, 256)
+
+	for !daRe.Match(response) {
+		n, err := reader.Read(buf)
+		if err != nil {
+			break
+		}
+
+		response = append(response, buf[:n]...)
+	}
+
+	return response
+}
+
+// daRe matches the reply to the primary device attributes.
+var daRe = regexp.MustCompile(`\x1b\[\?[\d;]*c`)
+
+// hasAttribute checks the primary device attributes (`ESC [ ? 62 ; 4 ; 22 c`)
+// for the given attribute.
+func hasAttribute(response []byte, attr string) bool {
+	da := daRe.Find(response)
+	if da == nil {
+		return false
+	}
+
+	attrs := strings.TrimSuffix(string(da[3:]), "c")
+
+	for _, a := range strings.Split(attrs, ";") {
+		if a == attr {
+			return true
+		}
+	}
+
+	return false
+}
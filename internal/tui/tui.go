@@ -5,62 +5,200 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
+	"github.com/corani/chip-8/internal/cast"
 	"github.com/corani/chip-8/internal/chip8"
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/keymap"
+	"github.com/corani/chip-8/internal/roms"
 )
 
-// TODO(daniel): `keyHold` needs to be tuned.
-const keyHold = 30 * time.Millisecond
-
-func New(log *log.Logger, chip8 *chip8.Chip8) *App {
+func New(log *log.Logger, chip8 *chip8.Chip8, cfg *config.Config) *App {
 	app := new(App)
 	app.log = log
 	app.chip8 = chip8
-	app.keyMap = map[string]uint8{
-		"1": 0x1, "2": 0x2, "3": 0x3, "4": 0xC,
-		"q": 0x4, "w": 0x5, "e": 0x6, "r": 0xD,
-		"a": 0x7, "s": 0x8, "d": 0x9, "f": 0xE,
-		"z": 0xA, "x": 0x0, "c": 0xB, "v": 0xF,
+	app.render = renderBlock
+
+	caps := detect()
+	app.keyboard = caps.keyboard
+	app.keyTap = cfg.KeyTap
+	app.keyDelay = cfg.KeyDelay
+	app.keyRepeat = cfg.KeyRepeat
+
+	if render, ok := renderers[cfg.Renderer]; ok {
+		app.render = render
+	} else if isGraphics(cfg.Renderer) {
+		app.initGraphics(cfg, caps.graphics)
+	} else if cfg.Renderer != "" {
+		log.Errorf("unknown renderer: %s", cfg.Renderer)
 	}
+
+	app.useConfig(cfg)
+	app.forROM = cfg.ForROM
 	app.keyDown = make(map[uint8]time.Duration)
+	app.keyRepeats = make(map[uint8]time.Duration)
+	app.output = newTerminal(log)
+	app.status.style = lipgloss.NewStyle().Reverse(true)
+	app.status.bell = cfg.Bell
 
-	app.program = tea.NewProgram(app, tea.WithAltScreen(), tea.WithFPS(60))
+	if chip8.ROMFile() == "" {
+		picker, err := roms.NewPicker(cfg.ROMDir)
+		if err != nil {
+			log.Errorf("failed to list roms: %v", err)
+		} else {
+			app.picker = picker
+		}
+	}
+
+	options := []tea.ProgramOption{
+		tea.WithAltScreen(), tea.WithFPS(60), tea.WithOutput(app.output),
+	}
+
+	if cfg.Cast != "" {
+		app.startCast(cfg.Cast)
+	}
+
+	if cfg.Keypad && app.graphics != nil {
+		log.Warnf("the keypad can't be shown next to graphics")
+	} else if cfg.Keypad {
+		app.keypad = newKeypad()
+		options = append(options, tea.WithMouseCellMotion())
+	}
+
+	app.program = tea.NewProgram(app, options...)
 	app.view.Grow(64*32 + 32)
 
 	return app
 }
 
 type App struct {
-	log     *log.Logger
-	chip8   *chip8.Chip8
-	keyMap  map[string]uint8
-	program *tea.Program
-	dt      time.Time
-	view    strings.Builder
-	keyDown map[uint8]time.Duration
+	log      *log.Logger
+	chip8    *chip8.Chip8
+	title    string
+	render   renderer
+	graphics *graphics
+	style    lipgloss.Style
+	keyMap   keymap.Keymap
+	forROM   func(name string) *config.Config
+	program  *tea.Program
+	dt       time.Time
+	view     strings.Builder
+	keyDown  map[uint8]time.Duration
+	// keyRepeats is how long another press of a key counts as a repeat.
+	keyRepeats map[uint8]time.Duration
+	// keyboard is set if the terminal reports key releases.
+	keyboard  bool
+	keyTap    time.Duration
+	keyDelay  time.Duration
+	keyRepeat time.Duration
+	status    status
+	keypad    *keypad
+	output    *terminal
+	cast      *cast.Recorder // set while recording
+	picker    *roms.Picker
+	height    int
 }
 
 func (app *App) Run() error {
 	app.dt = time.Now()
+	app.status.start = app.dt
 	_, err := app.program.Run()
 
+	if app.cast != nil {
+		if err := app.cast.Close(); err != nil {
+			app.log.Errorf("failed to save recording: %v", err)
+		}
+	}
+
 	return err
 }
 
 func (app *App) Init() tea.Cmd {
-	return func() tea.Msg {
-		return tea.SetWindowTitle("chip-8")
+	cmds := []tea.Cmd{tea.SetWindowTitle(app.title), tick}
+
+	if app.keyboard {
+		cmds = append(cmds, app.enableKeyboard)
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// enableKeyboard pushes the kitty keyboard protocol flags. This has to happen
+// after entering the alternate screen, as it has its own stack of flags.
+func (app *App) enableKeyboard() tea.Msg {
+	if err := app.output.pushKeyboard(keyboardFlags); err != nil {
+		app.log.Errorf("failed to enable the keyboard protocol: %v", err)
 	}
+
+	return nil
 }
 
 func (app *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		app.height = size.Height
+
+		if app.cast != nil {
+			if err := app.cast.Resize(size.Width, size.Height); err != nil {
+				app.log.Errorf("failed to record: %v", err)
+			}
+		}
+
+		if app.graphics != nil {
+			// leave room for the mode line.
+			app.graphics.cols, app.graphics.rows = size.Width, size.Height-1
+		}
+	}
+
+	if app.keyboard {
+		if key, event, ok := parseKittyKey(msg); ok {
+			if event == keyRelease {
+				msg = keyReleaseMsg(key)
+			} else {
+				msg = key
+			}
+		}
+	}
+
+	if app.picker != nil {
+		return app.updatePicker(msg)
+	}
+
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		if app.keypad != nil {
+			app.updateKeypad(msg)
+		}
+	case keyReleaseMsg:
+		if code, ok := app.keyMap.Key(keyName(tea.KeyMsg(msg))); ok {
+			app.chip8.KeyUp(code)
+		} else if tea.KeyMsg(msg).String() == "tab" {
+			app.chip8.SetFastForward(false)
+		}
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" || msg.String() == "esc" {
 			return app, tea.Quit
-		} else if code, ok := app.keyMap[msg.String()]; ok {
+		} else if msg.String() == "f2" {
+			app.chip8.TogglePause()
+		} else if msg.String() == "f3" {
+			app.chip8.Step()
+		} else if msg.String() == "f4" {
+			app.chip8.ToggleSlowMotion()
+		} else if msg.String() == "f5" {
+			app.chip8.Reset()
+		} else if msg.String() == "f6" {
+			if err := app.chip8.Reload(); err != nil {
+				app.log.Errorf("failed to reload rom: %v", err)
+			}
+		} else if code, ok := app.keyMap.Key(keyName(msg)); ok {
 			app.chip8.KeyDown(code)
-			app.keyDown[code] = keyHold
+			app.holdKey(code)
+		} else if msg.String() == "tab" && app.keyboard {
+			app.chip8.SetFastForward(true)
+		} else if msg.String() == "tab" {
+			// without key up events, fast-forward can't be held, so it's
+			// toggled instead.
+			app.chip8.SetFastForward(!app.chip8.FastForward())
 		}
 	}
 
@@ -68,9 +206,9 @@ func (app *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	dt := now.Sub(app.dt)
 	app.dt = now
 
-	// Bubbletea does not (currently) support key up events,
-	// so we simulate them by checking if a key has been "held"
-	// for a certain amount of time.
+	// Without the kitty keyboard protocol, there are no key up events, so
+	// we simulate them by checking if a key has been "held" for a certain
+	// amount of time.
 	for code, hold := range app.keyDown {
 		if hold > 0 {
 			app.keyDown[code] = hold - dt
@@ -83,30 +221,71 @@ func (app *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	for code, window := range app.keyRepeats {
+		app.keyRepeats[code] = max(0, window-dt)
+	}
+
 	app.chip8.Tick(dt)
 
-	return app, func() tea.Msg {
-		time.Sleep(16 * time.Millisecond)
+	// only the tick loop schedules the next tick, otherwise every other
+	// message would start another loop.
+	if _, ok := msg.(tickMsg); ok {
+		app.updateStatus(now)
+
+		return app, tick
+	}
+
+	return app, nil
+}
+
+// holdKey keeps a key pressed until it's released. If the terminal can't
+// report releases, a tap is held briefly. Another press before the terminal
+// would start repeating the key is taken as a repeat, which holds the key
+// until the next repeat is due.
+func (app *App) holdKey(code uint8) {
+	if app.keyboard {
+		// released by a `keyReleaseMsg`.
+		return
+	}
 
-		return true
+	if app.keyRepeats[code] > 0 {
+		app.keyDown[code] = app.keyRepeat
+	} else {
+		app.keyDown[code] = app.keyTap
 	}
+
+	// allow for the repeats to be late by one interval.
+	app.keyRepeats[code] = app.keyDelay + app.keyRepeat
+}
+
+// tickMsg drives the emulator at roughly 60 frames per second.
+type tickMsg struct{}
+
+func tick() tea.Msg {
+	time.Sleep(16 * time.Millisecond)
+
+	return tickMsg{}
 }
 
 func (app *App) View() string {
-	fb := app.chip8.Framebuffer()
+	if app.picker != nil {
+		return app.viewPicker()
+	}
 
 	app.view.Reset()
+	app.render(&app.view, app.chip8.Screen())
 
-	for y := 0; y < len(fb[0]); y++ {
-		for x := 0; x < len(fb); x++ {
-			if fb[x][y] == 0 {
-				app.view.WriteRune(' ')
-			} else {
-				app.view.WriteRune('█')
-			}
-		}
-		app.view.WriteRune('\n')
+	screen := strings.TrimSuffix(app.view.String(), "\n")
+
+	if app.graphics != nil {
+		return screen + "\n" + app.viewStatus()
+	}
+
+	screen = app.style.Render(screen)
+
+	if app.keypad != nil {
+		screen = app.viewKeypad(screen)
 	}
 
-	return app.view.String()
+	return screen + "\n" + app.viewStatus()
 }
@@ -0,0 +1,67 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Poll checks the file at the path returned by `path` every `interval`, and
+// calls `onChange` when its size or modification time changed. To avoid picking
+// up a file that is still being written, `onChange` is only called once the
+// file is stable for one interval. When the path changes, the new file is
+// watched from then on. Poll blocks until the context is done.
+func Poll(ctx context.Context, path func() string, interval time.Duration, onChange func()) {
+	watched := path()
+	last, _ := stat(watched)
+	pending := false
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if p := path(); p != watched {
+			watched = p
+			last, _ = stat(watched)
+			pending = false
+
+			continue
+		}
+
+		current, err := stat(watched)
+		if err != nil {
+			// the file might be in the middle of being replaced.
+			continue
+		}
+
+		switch {
+		case current != last:
+			last = current
+			pending = true
+		case pending:
+			pending = false
+
+			onChange()
+		}
+	}
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func stat(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, err
+	}
+
+	return fileState{info.Size(), info.ModTime()}, nil
+}
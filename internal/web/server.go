@@ -3,11 +3,11 @@ package web
 import (
 	"net/url"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/charmbracelet/log"
 	"github.com/corani/chip-8/internal/chip8"
+	"github.com/corani/chip-8/internal/config"
+	"github.com/corani/chip-8/internal/roms"
 	fiber "github.com/gofiber/fiber/v2"
 )
 
@@ -17,7 +17,7 @@ type App struct {
 	root string
 }
 
-func New(log *log.Logger, chip8 *chip8.Chip8) *App {
+func New(log *log.Logger, chip8 *chip8.Chip8, _ *config.Config) *App {
 	app := &App{
 		log: log,
 	}
@@ -87,48 +87,18 @@ func (app *App) HandleRom(c *fiber.Ctx) error {
 
 func (app *App) findRoms() {
 	// find the root folder of the project (the one with `go.mod`), then recursively
-	// search for `.ch8` files and collect them into `app.roms`.
-	root, err := os.Getwd()
+	// search for ROM files and collect them into `app.roms`.
+	root, err := roms.ProjectRoot()
 	if err != nil {
-		app.log.Errorf("Error getting working directory: %v", err)
+		app.log.Errorf("Error: %v", err)
 
 		return
 	}
 
-	for {
-		info, err := os.Stat(root + "/go.mod")
-		if err == nil && !info.IsDir() {
-			break
-		}
-
-		root = filepath.Dir(root)
-		if root == "/" {
-			app.log.Errorf("Error: could not find project root")
-
-			return
-		}
-	}
-
 	app.log.Infof("Project root: %s", root)
 	app.root = root + "/"
 
-	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			app.log.Errorf("Error walking path %s: %v", path, err)
-
-			return nil
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		if filepath.Ext(path) == ".ch8" {
-			app.roms = append(app.roms, strings.TrimPrefix(path, app.root))
-		}
-
-		return nil
-	})
+	app.roms, err = roms.Find(root)
 	if err != nil {
 		app.log.Errorf("Error walking project root: %v", err)
 	}